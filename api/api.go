@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/logger"
+	"github.com/epiphany-platform/health-monitor/maintenance"
+)
+
+type (
+	// silenceRequest POST body creating a silence, End or Duration seconds from Start
+	silenceRequest struct {
+		Probes   []string  `json:"probes"`
+		Comment  string    `json:"comment"`
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Duration int       `json:"duration"`
+	}
+)
+
+const (
+	// ConfigPath effective configuration endpoint
+	ConfigPath = "/config"
+	// MaintenancePath silences endpoint, DELETE MaintenancePath/<id> expires a silence
+	MaintenancePath = "/maintenance"
+)
+
+var (
+	// tokenFile bearer token authorizing maintenance changes, empty limits
+	// changes to loopback clients
+	tokenFile string
+)
+
+// authorized reports whether r may change maintenance silences, bearer
+// token re-read on every request so rotated tokens apply
+func authorized(r *http.Request) bool {
+	if tokenFile == "" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		return err == nil && ip != nil && ip.IsLoopback()
+	}
+
+	buf, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		logger.Err(err.Error())
+		return false
+	}
+	token := strings.TrimSpace(string(buf))
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// writeJSON encode v as response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warning(err.Error())
+	}
+}
+
+// config writes the effective configuration, format selected by ?format=yaml|json
+func config(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	var buf bytes.Buffer
+	if err := conf.Dump(&buf, format); err != nil {
+		logger.Warning(err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Write(buf.Bytes())
+}
+
+// silences list (GET), create (POST) or expire (DELETE /maintenance/<id>) silences
+func silences(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, MaintenancePath), "/")
+	if r.Method != http.MethodGet && !authorized(r) {
+		logger.Warning("Maintenance " + r.Method + " from " + r.RemoteAddr + " NOT authorized")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		writeJSON(w, http.StatusOK, maintenance.List())
+
+	case r.Method == http.MethodPost && id == "":
+		var req silenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		silence := maintenance.Silence{
+			Probes:  req.Probes,
+			Comment: req.Comment,
+			Start:   req.Start,
+			End:     req.End,
+		}
+		if req.Duration > 0 {
+			if silence.Start.IsZero() {
+				silence.Start = clock.Now()
+			}
+			silence.End = silence.Start.Add(time.Duration(req.Duration) * time.Second)
+		}
+		silence, err := maintenance.Add(silence)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Info("Maintenance silence " + silence.ID + " added until " + silence.End.Format(time.RFC3339))
+		writeJSON(w, http.StatusCreated, silence)
+
+	case r.Method == http.MethodDelete && id != "":
+		if !maintenance.Remove(id) {
+			http.NotFound(w, r)
+			return
+		}
+		logger.Info("Maintenance silence " + id + " removed")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// Run register API endpoints, served alongside the Prometheus metrics.
+// Maintenance changes require the bearer token read from token, loopback
+// clients only when token is empty.
+func Run(token string) {
+	tokenFile = token
+	http.HandleFunc(ConfigPath, config)
+	http.HandleFunc(MaintenancePath, silences)
+	http.HandleFunc(MaintenancePath+"/", silences)
+}
@@ -0,0 +1,280 @@
+package assert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/epiphany-platform/health-monitor/failure"
+)
+
+type (
+	// Spec response assertions as configured
+	Spec struct {
+		Status      []string `yaml:"Status,omitempty"`      // codes "200", classes "2xx" or ranges "200-299"
+		Body        string   `yaml:"Body,omitempty"`        // regular expression matched against body
+		JSON        []string `yaml:"JSON,omitempty"`        // `$.path == literal`, `$.path != literal` or `$.path`
+		Headers     []string `yaml:"Headers,omitempty"`     // header names required present
+		MaxBodySize int      `yaml:"MaxBodySize,omitempty"` // body bytes read, larger bodies fail
+	}
+
+	// Assertion compiled Spec
+	Assertion struct {
+		status  []codeRange
+		body    *regexp.Regexp
+		json    []jsonExpr
+		headers []string
+		maxBody int64
+	}
+
+	// codeRange inclusive range of status codes
+	codeRange struct {
+		lo, hi int
+	}
+
+	// Path JSON path selecting a value of a decoded document
+	Path struct {
+		text  string
+		steps []interface{} // string member or int index
+	}
+
+	// jsonExpr comparison of value selected by path against literal
+	jsonExpr struct {
+		text    string
+		path    *Path
+		op      string // "", "==" or "!="
+		literal interface{}
+	}
+)
+
+const (
+	// DefaultMaxBodySize body bytes read when MaxBodySize is not set
+	DefaultMaxBodySize = 1 << 20
+)
+
+var (
+	// pathRE single path step, .member or [index]
+	pathRE = regexp.MustCompile(`^(?:\.([A-Za-z_][A-Za-z0-9_-]*)|\[(\d+)\]|\['([^']*)'\])`)
+)
+
+// parseStatus parse "200", "2xx" or "200-299"
+func parseStatus(s string) (codeRange, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+		lo := int(s[0]-'0') * 100
+		return codeRange{lo, lo + 99}, nil
+	}
+	bounds := strings.SplitN(s, "-", 2)
+	lo, err := strconv.Atoi(bounds[0])
+	hi := lo
+	if err == nil && len(bounds) == 2 {
+		hi, err = strconv.Atoi(bounds[1])
+	}
+	if err != nil || lo < 100 || hi > 599 || lo > hi {
+		return codeRange{}, fmt.Errorf("Assert Status %q invalid", s)
+	}
+	return codeRange{lo, hi}, nil
+}
+
+// parsePath parse leading path of s, return path and remainder of s
+func parsePath(s string) (*Path, string, error) {
+	text := strings.TrimSpace(s)
+	if !strings.HasPrefix(text, "$") {
+		return nil, "", fmt.Errorf("JSON path %q must start with $", s)
+	}
+	p := &Path{}
+	rest := text[1:]
+	for m := pathRE.FindStringSubmatch(rest); m != nil; m = pathRE.FindStringSubmatch(rest) {
+		switch {
+		case m[1] != "":
+			p.steps = append(p.steps, m[1])
+		case m[2] != "":
+			n, _ := strconv.Atoi(m[2])
+			p.steps = append(p.steps, n)
+		default:
+			p.steps = append(p.steps, m[3])
+		}
+		rest = rest[len(m[0]):]
+	}
+	p.text = text[:len(text)-len(rest)]
+	return p, rest, nil
+}
+
+// ParsePath parse `$.a.b[0]['c d']`
+func ParsePath(s string) (*Path, error) {
+	p, rest, err := parsePath(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("JSON path %q invalid at %q", s, rest)
+	}
+	p.text = s
+	return p, nil
+}
+
+// String path as parsed
+func (p *Path) String() string {
+	return p.text
+}
+
+// Select value at path of decoded document
+func (p *Path) Select(doc interface{}) (interface{}, bool) {
+	for _, step := range p.steps {
+		switch key := step.(type) {
+		case string:
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := doc.([]interface{})
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			doc = arr[key]
+		}
+	}
+	return doc, true
+}
+
+// parseJSON parse `$.a.b[0] == "ok"`, the operator follows the path
+func parseJSON(s string) (jsonExpr, error) {
+	expr := jsonExpr{text: s}
+	path, rest, err := parsePath(s)
+	if err != nil {
+		return expr, fmt.Errorf("Assert %v", err)
+	}
+	expr.path = path
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return expr, nil
+	}
+	if !strings.HasPrefix(rest, "==") && !strings.HasPrefix(rest, "!=") {
+		return expr, fmt.Errorf("Assert JSON path %q invalid at %q", s, rest)
+	}
+	expr.op = rest[:2]
+	if err := json.Unmarshal([]byte(strings.TrimSpace(rest[2:])), &expr.literal); err != nil {
+		return expr, fmt.Errorf("Assert JSON %q literal invalid: %v", s, err)
+	}
+	return expr, nil
+}
+
+// Compile validate spec, Response is the legacy status code used when Status is empty
+func Compile(spec Spec, response string) (*Assertion, error) {
+	a := &Assertion{
+		headers: spec.Headers,
+		maxBody: int64(spec.MaxBodySize),
+	}
+	if a.maxBody < 0 {
+		return nil, errors.New("Assert MaxBodySize out-of-range")
+	}
+	if a.maxBody == 0 {
+		a.maxBody = DefaultMaxBodySize
+	}
+
+	status := spec.Status
+	if len(status) == 0 {
+		if fields := strings.Fields(response); len(fields) > 0 {
+			status = fields[:1]
+		} else {
+			status = []string{"2xx"}
+		}
+	}
+	for _, s := range status {
+		r, err := parseStatus(s)
+		if err != nil {
+			return nil, err
+		}
+		a.status = append(a.status, r)
+	}
+
+	if spec.Body != "" {
+		re, err := regexp.Compile(spec.Body)
+		if err != nil {
+			return nil, fmt.Errorf("Assert Body %v", err)
+		}
+		a.body = re
+	}
+
+	for _, s := range spec.JSON {
+		expr, err := parseJSON(s)
+		if err != nil {
+			return nil, err
+		}
+		a.json = append(a.json, expr)
+	}
+	return a, nil
+}
+
+// ReadsBody reports whether assertions examine the response body
+func (a *Assertion) ReadsBody() bool {
+	return a.body != nil || len(a.json) > 0
+}
+
+// eval expression against decoded document
+func (e *jsonExpr) eval(doc interface{}) error {
+	v, ok := e.path.Select(doc)
+	switch {
+	case !ok:
+		return &failure.BodyError{Reason: fmt.Sprintf("%s not found", e.text)}
+	case e.op == "==" && !reflect.DeepEqual(v, e.literal),
+		e.op == "!=" && reflect.DeepEqual(v, e.literal):
+		return &failure.BodyError{Reason: fmt.Sprintf("%s, got %v", e.text, v)}
+	}
+	return nil
+}
+
+// Check response against assertions, return body read up to MaxBodySize
+func (a *Assertion) Check(res *http.Response) ([]byte, error) {
+	code := res.StatusCode
+	matched := false
+	for _, r := range a.status {
+		matched = matched || (code >= r.lo && code <= r.hi)
+	}
+	if !matched {
+		return nil, &failure.StatusError{Status: res.Status}
+	}
+
+	for _, name := range a.headers {
+		if _, ok := res.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return nil, &failure.BodyError{Reason: fmt.Sprintf("header %s missing", name)}
+		}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, a.maxBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > a.maxBody {
+		return nil, &failure.BodyError{Reason: fmt.Sprintf("body exceeds %d bytes", a.maxBody)}
+	}
+
+	if a.body != nil && !a.body.Match(body) {
+		return nil, &failure.BodyError{Reason: fmt.Sprintf("body NOT matching %s", a.body)}
+	}
+
+	if len(a.json) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, &failure.BodyError{Reason: fmt.Sprintf("body NOT JSON: %v", err)}
+		}
+		for i := range a.json {
+			if err := a.json[i].eval(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return body, nil
+}
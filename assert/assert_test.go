@@ -0,0 +1,62 @@
+package assert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	for _, tc := range []struct {
+		expr    string
+		path    string
+		steps   []interface{}
+		op      string
+		literal interface{}
+	}{
+		{`$.a`, `$.a`, []interface{}{"a"}, "", nil},
+		{`$.items[0].id == "x"`, `$.items[0].id`, []interface{}{"items", 0, "id"}, "==", "x"},
+		{`$.a != "x==y"`, `$.a`, []interface{}{"a"}, "!=", "x==y"},
+		{`$['a==b'] == 1`, `$['a==b']`, []interface{}{"a==b"}, "==", float64(1)},
+		{` $['a != b']!=false `, `$['a != b']`, []interface{}{"a != b"}, "!=", false},
+	} {
+		expr, err := parseJSON(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if expr.path.String() != tc.path || !reflect.DeepEqual(expr.path.steps, tc.steps) {
+			t.Errorf("%s: path %s %v, want %s %v", tc.expr, expr.path, expr.path.steps, tc.path, tc.steps)
+		}
+		if expr.op != tc.op || !reflect.DeepEqual(expr.literal, tc.literal) {
+			t.Errorf("%s: %q %v, want %q %v", tc.expr, expr.op, expr.literal, tc.op, tc.literal)
+		}
+	}
+}
+
+func TestParseJSONInvalid(t *testing.T) {
+	for _, expr := range []string{
+		`a == 1`,
+		`$.a ~ 1`,
+		`$.a == `,
+		`$.a == x`,
+		`$..a`,
+	} {
+		if _, err := parseJSON(expr); err == nil {
+			t.Errorf("%s: parseJSON = nil, want error", expr)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	if _, err := ParsePath(`$.a == 1`); err == nil {
+		t.Error("ParsePath accepted trailing expression")
+	}
+	p, err := ParsePath(`$.a.b[2]['c d']`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{0, 1, map[string]interface{}{"c d": "ok"}}}}
+	if v, ok := p.Select(doc); !ok || v != "ok" {
+		t.Errorf("Select = %v %v, want ok", v, ok)
+	}
+}
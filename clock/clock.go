@@ -0,0 +1,85 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Timer single event timer created by a Clock
+	Timer interface {
+		C() <-chan time.Time
+		Stop() bool
+	}
+
+	// Clock source of time for the scheduler and probes
+	Clock interface {
+		Now() time.Time
+		NewTimer(d time.Duration) Timer
+	}
+
+	// realClock wall clock backed by package time
+	realClock struct{}
+
+	// realTimer time.Timer adapter
+	realTimer struct {
+		*time.Timer
+	}
+)
+
+var (
+	mutex   sync.RWMutex
+	current Clock = realClock{}
+)
+
+// Now current wall clock time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer wall clock timer firing after d
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// C timer expiry channel
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// Real return the wall clock
+func Real() Clock {
+	return realClock{}
+}
+
+// Set replace clock in use, nil restores the wall clock
+func Set(c Clock) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	current = c
+}
+
+// Get return clock in use
+func Get() Clock {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Now current time of clock in use
+func Now() time.Time {
+	return Get().Now()
+}
+
+// Since time elapsed from t on clock in use
+func Since(t time.Time) time.Duration {
+	return Get().Now().Sub(t)
+}
+
+// NewTimer timer firing after d on clock in use
+func NewTimer(d time.Duration) Timer {
+	return Get().NewTimer(d)
+}
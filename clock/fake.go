@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Fake clock advanced explicitly, for deterministic scheduler and probe tests
+	Fake struct {
+		mutex  sync.Mutex
+		now    time.Time
+		timers []*fakeTimer
+	}
+
+	// fakeTimer timer firing when Fake clock passes deadline
+	fakeTimer struct {
+		clock    *Fake
+		deadline time.Time
+		c        chan time.Time
+	}
+)
+
+// NewFake fake clock reading now until advanced
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now current fake time
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// NewTimer fake timer firing once the clock is advanced by d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := &fakeTimer{
+		clock:    f,
+		deadline: f.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.c <- f.now
+		return t
+	}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+// Advance move clock forward by d firing expired timers in deadline order
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		return f.timers[i].deadline.Before(f.timers[j].deadline)
+	})
+
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- f.now
+	}
+	f.timers = pending
+}
+
+// Pending number of timers not yet fired
+func (f *Fake) Pending() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.timers)
+}
+
+// C timer expiry channel
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop prevent timer firing, false when already fired or stopped
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i, pending := range f.timers {
+		if pending == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package conf
+
+import (
+	"errors"
+	"time"
+)
+
+// isBackoffNormalize validate exponential backoff of retry and recovery delays
+func isBackoffNormalize(conf *Conf) error {
+	if conf.Env.BackoffFactor == 0 {
+		conf.Env.BackoffFactor = 1
+	}
+	if !(conf.Env.BackoffFactor >= 1 && conf.Env.BackoffFactor <= 10) {
+		return errors.New("YAML BackoffFactor out-of-range")
+	}
+
+	if conf.Env.BackoffMax == 0 {
+		conf.Env.BackoffMax = 600
+	}
+	if !(conf.Env.BackoffMax >= conf.Env.RecoveryDelay && conf.Env.BackoffMax <= 86400) {
+		return errors.New("YAML BackoffMax out-of-range")
+	}
+
+	if !(conf.Env.BackoffJitter >= 0 && conf.Env.BackoffJitter <= 50) {
+		return errors.New("YAML BackoffJitter out-of-range")
+	}
+
+	if conf.Env.BackoffReset == 0 {
+		conf.Env.BackoffReset = 300
+	}
+	if !(conf.Env.BackoffReset >= conf.Env.Interval && conf.Env.BackoffReset <= 86400) {
+		return errors.New("YAML BackoffReset out-of-range")
+	}
+	return nil
+}
+
+// Backoff base seconds multiplied by BackoffFactor per step, capped at
+// BackoffMax and varied by BackoffJitter percent. BackoffFactor 1 keeps
+// base fixed.
+func (c *Conf) Backoff(base, step int) time.Duration {
+	d := time.Duration(base) * time.Second
+	max := time.Duration(c.Env.BackoffMax) * time.Second
+	for i := 0; i < step && d < max && c.Env.BackoffFactor > 1; i++ {
+		d *= time.Duration(c.Env.BackoffFactor)
+	}
+	if d > max {
+		d = max
+	}
+	return jittered(d, c.Env.BackoffJitter)
+}
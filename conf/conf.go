@@ -3,70 +3,171 @@ package conf
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/docker/docker/client"
+	"github.com/epiphany-platform/health-monitor/assert"
+	"github.com/epiphany-platform/health-monitor/failure"
 	"github.com/epiphany-platform/health-monitor/logger"
 	"github.com/epiphany-platform/health-monitor/timer"
 	"gopkg.in/yaml.v3"
 )
 
 type (
-	// Conf Liveness monitor configuration
+	// launcher first timer of probes of a running package
+	launcher struct {
+		pkgType    int
+		pkgSubType int
+	}
+
+	// Conf Liveness monitor configuration, immutable once loaded
 	Conf struct {
-		RetryCounter int
-		RestartCount uint32
-		Env          struct {
-			Name            string `yaml:"Name"`
-			Package         string `yaml:"Package"`
-			ActionFatal     bool   `yaml:"ActionFatal"`
-			IP              string `yaml:"IP,omitempty"`
-			Interval        int    `yaml:"Interval"`
-			Path            string `yaml:"Path,omitempty"`
-			Port            int    `yaml:"Port,omitempty"`
-			RequestType     string `yaml:"RequestType,omitempty"`
-			Response        string `yaml:"Response,omitempty"`
-			Retries         int    `yaml:"Retries"`
-			RetryDelay      int    `yaml:"RetryDelay"`
-			RecoveryDelay   int    `yaml:"RecoveryDelay"`
-			ProtocolTimeout int    `yaml:"ProtocolTimeout"`
+		*State      `yaml:"-"`
+		secrets     map[string][]string
+		cron        *timer.Cron
+		windows     []window
+		maintenance []window
+		policy      map[failure.Class]failure.Action
+		target      *url.URL
+		assertion   *assert.Assertion
+		expect      *regexp.Regexp
+		Env         struct {
+			Name             string            `yaml:"Name"`
+			Package          string            `yaml:"Package"`
+			ActionFatal      bool              `yaml:"ActionFatal"`
+			IP               string            `yaml:"IP,omitempty"`
+			Scheme           string            `yaml:"Scheme,omitempty"`
+			URL              string            `yaml:"URL,omitempty"`
+			Socket           string            `yaml:"Socket,omitempty"`
+			Redirects        string            `yaml:"Redirects,omitempty"`
+			MaxRedirects     int               `yaml:"MaxRedirects,omitempty"`
+			Proxy            string            `yaml:"Proxy,omitempty"`
+			SourceIP         string            `yaml:"SourceIP,omitempty"`
+			Interface        string            `yaml:"Interface,omitempty"`
+			Resolve          map[string]string `yaml:"Resolve,omitempty"`
+			TLS              TLS               `yaml:"TLS,omitempty"`
+			Assert           assert.Spec       `yaml:"Assert,omitempty"`
+			Request          Request           `yaml:"Request,omitempty"`
+			Steps            []Step            `yaml:"Steps,omitempty"`
+			WebSocket        WebSocket         `yaml:"WebSocket,omitempty"`
+			Interval         int               `yaml:"Interval"`
+			Path             string            `yaml:"Path,omitempty"`
+			Port             int               `yaml:"Port,omitempty"`
+			RequestType      string            `yaml:"RequestType,omitempty"`
+			Response         string            `yaml:"Response,omitempty"`
+			Retries          int               `yaml:"Retries"`
+			RetryDelay       int               `yaml:"RetryDelay"`
+			RecoveryDelay    int               `yaml:"RecoveryDelay"`
+			ProtocolTimeout  int               `yaml:"ProtocolTimeout"`
+			InitialDelay     int               `yaml:"InitialDelay,omitempty"`
+			Jitter           int               `yaml:"Jitter,omitempty"`
+			Splay            int               `yaml:"Splay,omitempty"`
+			Schedule         string            `yaml:"Schedule,omitempty"`
+			Windows          []Window          `yaml:"Windows,omitempty"`
+			Once             bool              `yaml:"Once,omitempty"`
+			Maintenance      []Window          `yaml:"Maintenance,omitempty"`
+			DependsOn        []string          `yaml:"DependsOn,omitempty"`
+			SuccessThreshold int               `yaml:"SuccessThreshold,omitempty"`
+			FlapWindow       int               `yaml:"FlapWindow,omitempty"`
+			FlapThreshold    int               `yaml:"FlapThreshold,omitempty"`
+			FailureWindow    int               `yaml:"FailureWindow,omitempty"`
+			FailureThreshold int               `yaml:"FailureThreshold,omitempty"`
+			FailurePeriod    int               `yaml:"FailurePeriod,omitempty"`
+			FailurePercent   int               `yaml:"FailurePercent,omitempty"`
+			Errors           map[string]string `yaml:"Errors,omitempty"`
+			VerifyInterval   int               `yaml:"VerifyInterval,omitempty"`
+			VerifyTimeout    int               `yaml:"VerifyTimeout,omitempty"`
+			MaxUnrecovered   int               `yaml:"MaxUnrecovered,omitempty"`
+			BackoffFactor    int               `yaml:"BackoffFactor,omitempty"`
+			BackoffMax       int               `yaml:"BackoffMax,omitempty"`
+			BackoffJitter    int               `yaml:"BackoffJitter,omitempty"`
+			BackoffReset     int               `yaml:"BackoffReset,omitempty"`
+			WarnLatency      int               `yaml:"WarnLatency,omitempty"`
+			CritLatency      int               `yaml:"CritLatency,omitempty"`
+			DegradedChecks   int               `yaml:"DegradedChecks,omitempty"`
 		} `yaml:"Env"`
 	}
 )
 
 var (
-	// Confs Array of Liveness monitor configuration Probe
-	Confs = make(map[string]*Conf)
+	// mutex guards confs, replaced as a whole on reload
+	mutex sync.RWMutex
+	// confs Liveness monitor configuration Probes by name
+	confs = make(map[string]*Conf)
+	// launchers timer type and subtype of running packages by lower case name
+	launchers = make(map[string]launcher)
 )
 
-// Run launch specified client timer
+// Get return current configuration of named probe, nil when not configured
+func Get(name string) *Conf {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return confs[name]
+}
+
+// All return current probe configurations ordered by name
+func All() []*Conf {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	all := make([]*Conf, 0, len(confs))
+	for _, conf := range confs {
+		all = append(all, conf)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Env.Name < all[j].Env.Name
+	})
+	return all
+}
+
+// launch arm first probe timer of conf
+func launch(conf *Conf, pkgType, pkgSubType int) {
+	timer.Launch(
+		timer.Name(conf.Env.Name),
+		timer.At(timer.ScheduleFunc(conf.First)),
+		timer.Type(pkgType),
+		timer.SubType(pkgSubType),
+		timer.User(conf),
+	)
+}
+
+// Run launch specified client timer, probes of pkg added on reload are
+// launched by Unmarshal
 func Run(pkg string, pkgType, pkgSubType int) {
-	for _, conf := range Confs {
+	mutex.Lock()
+	launchers[strings.ToLower(pkg)] = launcher{pkgType, pkgSubType}
+	mutex.Unlock()
+
+	for _, conf := range All() {
 		if strings.EqualFold(conf.Env.Package, pkg) {
-			timer.Launch(
-				timer.Name(conf.Env.Name),
-				timer.Timeout(conf.Env.Interval),
-				timer.Type(pkgType),
-				timer.SubType(pkgSubType),
-				timer.User(conf),
-			)
+			launch(conf, pkgType, pkgSubType)
 		}
 	}
 }
 
 // Len return the number liveness probes configure
 func Len() int {
-	return len(Confs)
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(confs)
 }
 
 // New allocates memory and return pointer newly allocated zero value of that type
 func New() *Conf {
-	return new(Conf)
+	return &Conf{
+		State:   new(State),
+		secrets: make(map[string][]string),
+	}
 }
 
 func isDockerNormlize(conf *Conf) error {
@@ -85,16 +186,38 @@ func isDockerNormlize(conf *Conf) error {
 	return nil
 }
 
-func isHTTPNormalize(conf *Conf) error {
-	if strings.EqualFold("http", conf.Env.Package) {
-		if net.ParseIP(conf.Env.IP) == nil {
-			return errors.New("YAML IP address out-of-bound")
+// isDependsNormalize ensure DependsOn names configured probes without cycles
+func isDependsNormalize(loaded map[string]*Conf) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	marks := make(map[string]int)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("YAML DependsOn cycle through %s", name)
+		case visited:
+			return nil
 		}
-		if conf.Env.Port == 0 || conf.Env.Path == "" {
-			return errors.New("YAML Port or Path missing")
+		marks[name] = visiting
+		for _, dep := range loaded[name].Env.DependsOn {
+			if loaded[dep] == nil {
+				return fmt.Errorf("YAML %s DependsOn %s NOT defined", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
 		}
-		if conf.Env.RequestType == "" {
-			return errors.New("YAML Request Typpe must be specified")
+		marks[name] = visited
+		return nil
+	}
+
+	for name := range loaded {
+		if err := visit(name); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -130,6 +253,61 @@ func IsNormalize(conf *Conf) error {
 		return errors.New("YAML ProtocolTimeout out-of-range")
 	}
 
+	if !(conf.Env.InitialDelay >= 0 && conf.Env.InitialDelay <= 3600) {
+		return errors.New("YAML InitialDelay out-of-range")
+	}
+
+	if !(conf.Env.Jitter >= 0 && conf.Env.Jitter <= 50) {
+		return errors.New("YAML Jitter out-of-range")
+	}
+
+	if !(conf.Env.Splay >= 0 && conf.Env.Splay <= 3600) {
+		return errors.New("YAML Splay out-of-range")
+	}
+
+	if conf.Env.SuccessThreshold == 0 {
+		conf.Env.SuccessThreshold = 1
+	}
+
+	if !(conf.Env.SuccessThreshold >= 1 && conf.Env.SuccessThreshold <= 10) {
+		return errors.New("YAML SuccessThreshold out-of-range")
+	}
+
+	if !(conf.Env.FlapWindow >= 0 && conf.Env.FlapWindow <= 3600) {
+		return errors.New("YAML FlapWindow out-of-range")
+	}
+
+	if !(conf.Env.FlapThreshold == 0 || (conf.Env.FlapThreshold >= 2 && conf.Env.FlapThreshold <= 100)) {
+		return errors.New("YAML FlapThreshold out-of-range")
+	}
+
+	if (conf.Env.FlapWindow == 0) != (conf.Env.FlapThreshold == 0) {
+		return errors.New("YAML FlapWindow and FlapThreshold must be set together")
+	}
+
+	if err := isFailureNormalize(conf); err != nil {
+		return err
+	}
+	if err := isErrorsNormalize(conf); err != nil {
+		return err
+	}
+
+	if err := isVerifyNormalize(conf); err != nil {
+		return err
+	}
+
+	if err := isBackoffNormalize(conf); err != nil {
+		return err
+	}
+
+	if err := isLatencyNormalize(conf); err != nil {
+		return err
+	}
+
+	if err := isScheduleNormalize(conf); err != nil {
+		return err
+	}
+
 	if err := isDockerNormlize(conf); err != nil {
 		return err
 	}
@@ -137,32 +315,58 @@ func IsNormalize(conf *Conf) error {
 	if err := isHTTPNormalize(conf); err != nil {
 		return err
 	}
+
+	if err := isWebSocketNormalize(conf); err != nil {
+		return err
+	}
 	return nil
 }
 
-// Unmarshal YAML conf file
-func Unmarshal(b []byte) (err error) {
+// Unmarshal YAML conf file, replacing current configuration. Probes
+// retained across reload keep their runtime State, added probes of running
+// packages are launched, a probe moved to another package is added anew.
+// An invalid conf file returns an error and leaves the current
+// configuration in place.
+func Unmarshal(b []byte) error {
+	loaded := make(map[string]*Conf)
 	dec := yaml.NewDecoder(bytes.NewReader(b))
 	for {
 		conf := New()
-		if err = dec.Decode(conf); err == nil {
-			if err := IsNormalize(conf); err != nil {
-				logger.Err(err.Error())
-				panic(err)
-			}
-			if Confs[conf.Env.Name] == nil {
-				Confs[conf.Env.Name] = conf
-			} else {
-				Confs[conf.Env.Name].Env = conf.Env
-			}
-			continue
-		}
+		err := dec.Decode(conf)
 		if err == io.EOF {
-			return nil
+			break
 		}
-		logger.Err(err.Error())
-		panic(err)
+		if err != nil {
+			return err
+		}
+		if err := Interpolate(conf); err != nil {
+			return err
+		}
+		if err := IsNormalize(conf); err != nil {
+			return err
+		}
+		loaded[conf.Env.Name] = conf
+	}
+	if err := isDependsNormalize(loaded); err != nil {
+		return err
 	}
+
+	added := make(map[*Conf]launcher)
+	mutex.Lock()
+	for name, conf := range loaded {
+		if prev := confs[name]; prev != nil && strings.EqualFold(prev.Env.Package, conf.Env.Package) {
+			conf.State = prev.State
+		} else if l, ok := launchers[strings.ToLower(conf.Env.Package)]; ok {
+			added[conf] = l
+		}
+	}
+	confs = loaded
+	mutex.Unlock()
+
+	for conf, l := range added {
+		launch(conf, l.pkgType, l.pkgSubType)
+	}
+	return nil
 }
 
 // Load YAML conf into memory
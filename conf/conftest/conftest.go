@@ -0,0 +1,27 @@
+// Package conftest provides healthd.yml fixtures shared by package tests.
+package conftest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Probe conf document of probe name in pkg, the minimal valid retry and
+// recovery settings extended by extra, indented Env keys such as
+// "  URL: http://127.0.0.1/healthz\n"
+func Probe(name, pkg, extra string) string {
+	return fmt.Sprintf(`Env:
+  Name: %s
+  Package: %s
+  Interval: 5
+  Retries: 3
+  RetryDelay: 5
+  RecoveryDelay: 10
+  ProtocolTimeout: 2
+%s`, name, pkg, extra)
+}
+
+// File conf file of docs
+func File(docs ...string) []byte {
+	return []byte(strings.Join(docs, "---\n"))
+}
@@ -0,0 +1,133 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Redacted replaces secret values within configuration dumps
+	Redacted = "<redacted>"
+)
+
+var (
+	// secretKeys YAML key suffixes whose values are always redacted
+	secretKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+	// stepPathRE YAML path of the Path of a transaction step
+	stepPathRE = regexp.MustCompile(`^Env\.Steps\[\d+\]\.Path$`)
+)
+
+// isSecretKey reports whether the last element of path names a secret
+func isSecretKey(path string) bool {
+	key := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, suffix := range secretKeys {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactQuery replaces the values of query parameters
+func redactQuery(rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redacted
+	}
+	params := make([]string, 0, len(query))
+	for key := range query {
+		params = append(params, url.QueryEscape(key)+"="+Redacted)
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// redactURL strips userinfo and query values of URL s, both commonly
+// carrying credentials
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Redacted
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = redactQuery(u.RawQuery)
+	}
+	return u.String()
+}
+
+// redactPath replaces query values of path s, kept verbatim otherwise as it
+// may hold {{name}} references
+func redactPath(s string) string {
+	if i := strings.Index(s, "?"); i >= 0 && i < len(s)-1 {
+		return s[:i+1] + redactQuery(s[i+1:])
+	}
+	return s
+}
+
+// Redact returns a deep copy of conf with secret values replaced
+func Redact(conf *Conf) (*Conf, error) {
+	buf, err := yaml.Marshal(&conf.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := New()
+	if err := yaml.Unmarshal(buf, &redacted.Env); err != nil {
+		return nil, err
+	}
+
+	err = walkStrings(reflect.ValueOf(&redacted.Env), "Env", func(path, s string) (string, error) {
+		switch {
+		case s == "":
+		case conf.IsSecret(path) || isSecretKey(path):
+			return Redacted, nil
+		case path == "Env.URL" || path == "Env.Proxy":
+			return redactURL(s), nil
+		case path == "Env.Path" || stepPathRE.MatchString(path):
+			return redactPath(s), nil
+		}
+		return s, nil
+	})
+	return redacted, err
+}
+
+// Dump writes the loaded configuration, secrets redacted, as "yaml" or "json"
+func Dump(w io.Writer, format string) error {
+	var confs []*Conf
+	for _, conf := range All() {
+		redacted, err := Redact(conf)
+		if err != nil {
+			return err
+		}
+		confs = append(confs, redacted)
+	}
+
+	switch strings.ToLower(format) {
+	case "", "yaml":
+		enc := yaml.NewEncoder(w)
+		for _, conf := range confs {
+			if err := enc.Encode(map[string]interface{}{"Env": &conf.Env}); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	case "json":
+		docs := make([]map[string]interface{}, 0, len(confs))
+		for _, conf := range confs {
+			docs = append(docs, map[string]interface{}{"Env": &conf.Env})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(docs)
+	}
+	return fmt.Errorf("Dump format %s NOT supported", format)
+}
@@ -0,0 +1,102 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/failure"
+)
+
+const (
+	// OutcomeCapacity check outcomes retained per probe for FailurePeriod
+	OutcomeCapacity = 1024
+	// minPeriodChecks checks within FailurePeriod before FailurePercent applies
+	minPeriodChecks = 3
+)
+
+// isFailureNormalize validate failure ratio policy
+func isFailureNormalize(conf *Conf) error {
+	if (conf.Env.FailureWindow == 0) != (conf.Env.FailureThreshold == 0) {
+		return errors.New("YAML FailureWindow and FailureThreshold must be set together")
+	}
+	if conf.Env.FailureWindow != 0 {
+		if !(conf.Env.FailureWindow >= 2 && conf.Env.FailureWindow <= 100) {
+			return errors.New("YAML FailureWindow out-of-range")
+		}
+		if !(conf.Env.FailureThreshold >= 1 && conf.Env.FailureThreshold <= conf.Env.FailureWindow) {
+			return errors.New("YAML FailureThreshold out-of-range")
+		}
+	}
+
+	if (conf.Env.FailurePeriod == 0) != (conf.Env.FailurePercent == 0) {
+		return errors.New("YAML FailurePeriod and FailurePercent must be set together")
+	}
+	if conf.Env.FailurePeriod != 0 {
+		if !(conf.Env.FailurePeriod >= 10 && conf.Env.FailurePeriod <= 3600) {
+			return errors.New("YAML FailurePeriod out-of-range")
+		}
+		if !(conf.Env.FailurePercent >= 1 && conf.Env.FailurePercent <= 100) {
+			return errors.New("YAML FailurePercent out-of-range")
+		}
+	}
+	return nil
+}
+
+// isErrorsNormalize validate error class policy, unlisted classes fail
+func isErrorsNormalize(conf *Conf) error {
+	conf.policy = make(map[failure.Class]failure.Action, len(conf.Env.Errors))
+	for name, value := range conf.Env.Errors {
+		class, err := failure.ParseClass(name)
+		if err != nil {
+			return fmt.Errorf("YAML Errors %v", err)
+		}
+		action, err := failure.ParseAction(value)
+		if err != nil {
+			return fmt.Errorf("YAML Errors %s %v", name, err)
+		}
+		conf.policy[class] = action
+	}
+	return nil
+}
+
+// ErrorAction action configured for error class, failure.Fail by default
+func (c *Conf) ErrorAction(class failure.Class) failure.Action {
+	if action, ok := c.policy[class]; ok {
+		return action
+	}
+	return failure.Fail
+}
+
+// RatioEnabled reports whether a failure ratio policy is configured
+func (c *Conf) RatioEnabled() bool {
+	return c.Env.FailureWindow > 0 || c.Env.FailurePeriod > 0
+}
+
+// RecordOutcome add check outcome to probe failure ratio window
+func (c *Conf) RecordOutcome(failed bool, now time.Time) {
+	switch {
+	case c.Env.FailurePeriod > 0:
+		c.Record(failed, now, OutcomeCapacity)
+	case c.Env.FailureWindow > 0:
+		c.Record(failed, now, c.Env.FailureWindow)
+	}
+}
+
+// RatioExceeded reports whether FailureThreshold of the last FailureWindow
+// checks, or FailurePercent of checks within FailurePeriod, failed.
+func (c *Conf) RatioExceeded(now time.Time) (failed, total int, exceeded bool) {
+	if c.Env.FailureWindow > 0 {
+		failed, total = c.Outcomes(c.Env.FailureWindow, time.Time{})
+		if failed >= c.Env.FailureThreshold {
+			return failed, total, true
+		}
+	}
+	if c.Env.FailurePeriod > 0 {
+		failed, total = c.Outcomes(0, now.Add(-time.Duration(c.Env.FailurePeriod)*time.Second))
+		if total >= minPeriodChecks && failed*100 >= c.Env.FailurePercent*total {
+			return failed, total, true
+		}
+	}
+	return failed, total, false
+}
@@ -0,0 +1,75 @@
+package conf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/conf/conftest"
+)
+
+// ratio load probe name with failure ratio policy extra, return its Conf
+func ratio(t *testing.T, name, extra string) *Conf {
+	load(t, conftest.File(conftest.Probe(name, "ratio", extra)))
+	return Get(name)
+}
+
+func TestRatioExceeded(t *testing.T) {
+	start := time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC)
+	type outcome struct {
+		failed bool
+		at     int // secs after start
+	}
+	for _, c := range []struct {
+		name          string
+		extra         string
+		outcomes      []outcome
+		failed, total int
+		exceeded      bool
+	}{
+		{"window below threshold", "  FailureWindow: 3\n  FailureThreshold: 2\n",
+			[]outcome{{true, 0}, {false, 5}, {false, 10}, {true, 15}}, 1, 3, false},
+		{"window wraps", "  FailureWindow: 3\n  FailureThreshold: 2\n",
+			[]outcome{{true, 0}, {false, 5}, {false, 10}, {true, 15}, {true, 20}}, 2, 3, true},
+		{"window wraps twice", "  FailureWindow: 3\n  FailureThreshold: 3\n",
+			[]outcome{{true, 0}, {true, 5}, {true, 10}, {false, 15}, {false, 20}, {false, 25}, {true, 30}, {true, 35}, {true, 40}}, 3, 3, true},
+		{"percent fewer than 3 checks", "  FailurePeriod: 60\n  FailurePercent: 50\n",
+			[]outcome{{true, 0}, {true, 5}}, 2, 2, false},
+		{"percent reached", "  FailurePeriod: 60\n  FailurePercent: 50\n",
+			[]outcome{{true, 0}, {false, 5}, {true, 10}}, 2, 3, true},
+		{"percent below", "  FailurePeriod: 60\n  FailurePercent: 50\n",
+			[]outcome{{true, 0}, {false, 5}, {false, 10}}, 1, 3, false},
+		{"percent outside period", "  FailurePeriod: 60\n  FailurePercent: 50\n",
+			[]outcome{{true, 0}, {true, 5}, {true, 70}, {false, 75}}, 1, 2, false},
+	} {
+		conf := ratio(t, "ratio", c.extra)
+		conf.ClearOutcomes()
+		var now time.Time
+		for _, o := range c.outcomes {
+			now = start.Add(time.Duration(o.at) * time.Second)
+			conf.RecordOutcome(o.failed, now)
+		}
+		failed, total, exceeded := conf.RatioExceeded(now)
+		if failed != c.failed || total != c.total || exceeded != c.exceeded {
+			t.Errorf("%s: RatioExceeded = %d %d %v, want %d %d %v",
+				c.name, failed, total, exceeded, c.failed, c.total, c.exceeded)
+		}
+	}
+}
+
+func TestRatioResetOnWindowChange(t *testing.T) {
+	now := time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC)
+	conf := ratio(t, "ratio-reload", "  FailureWindow: 3\n  FailureThreshold: 2\n")
+	conf.ClearOutcomes()
+	conf.RecordOutcome(true, now)
+	conf.RecordOutcome(true, now)
+	if _, _, exceeded := conf.RatioExceeded(now); !exceeded {
+		t.Fatal("RatioExceeded = false before reload")
+	}
+
+	// reload keeps State, outcomes of the former window are dropped
+	conf = ratio(t, "ratio-reload", "  FailureWindow: 4\n  FailureThreshold: 2\n")
+	conf.RecordOutcome(false, now)
+	if failed, total, exceeded := conf.RatioExceeded(now); failed != 0 || total != 1 || exceeded {
+		t.Errorf("RatioExceeded after reload = %d %d %v, want 0 1 false", failed, total, exceeded)
+	}
+}
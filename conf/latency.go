@@ -0,0 +1,36 @@
+package conf
+
+import (
+	"errors"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/failure"
+)
+
+// isLatencyNormalize validate latency thresholds in milliseconds
+func isLatencyNormalize(conf *Conf) error {
+	timeout := conf.Env.ProtocolTimeout * 1000
+	if !(conf.Env.WarnLatency >= 0 && conf.Env.WarnLatency < timeout) {
+		return errors.New("YAML WarnLatency out-of-range")
+	}
+	if !(conf.Env.CritLatency == 0 || (conf.Env.CritLatency > conf.Env.WarnLatency && conf.Env.CritLatency < timeout)) {
+		return errors.New("YAML CritLatency out-of-range")
+	}
+	if !(conf.Env.DegradedChecks >= 0 && conf.Env.DegradedChecks <= 100) {
+		return errors.New("YAML DegradedChecks out-of-range")
+	}
+	if conf.Env.DegradedChecks > 0 && conf.Env.WarnLatency == 0 {
+		return errors.New("YAML DegradedChecks requires WarnLatency")
+	}
+	return nil
+}
+
+// GradeLatency latency of successful check, degraded at WarnLatency,
+// failed with failure.LatencyError at CritLatency
+func (c *Conf) GradeLatency(latency time.Duration) (bool, error) {
+	if crit := time.Duration(c.Env.CritLatency) * time.Millisecond; crit > 0 && latency >= crit {
+		return false, &failure.LatencyError{Latency: latency, Limit: crit}
+	}
+	warn := time.Duration(c.Env.WarnLatency) * time.Millisecond
+	return warn > 0 && latency >= warn, nil
+}
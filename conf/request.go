@@ -0,0 +1,85 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Request HTTP request settings of a probe
+	Request struct {
+		Headers         map[string]string `yaml:"Headers,omitempty"`
+		Host            string            `yaml:"Host,omitempty"`
+		Body            string            `yaml:"Body,omitempty"`
+		Username        string            `yaml:"Username,omitempty"`
+		Password        string            `yaml:"Password,omitempty"`
+		BearerToken     string            `yaml:"BearerToken,omitempty"`
+		BearerTokenFile string            `yaml:"BearerTokenFile,omitempty"`
+	}
+)
+
+// isRequestNormalize validate request settings against method
+func isRequestNormalize(method string, r *Request) error {
+	for name := range r.Headers {
+		if strings.EqualFold(name, "Host") {
+			return errors.New("YAML Request Host header must be set by Host")
+		}
+	}
+	if r.Body != "" && (strings.EqualFold(method, http.MethodHead) || strings.EqualFold(method, http.MethodGet)) {
+		return fmt.Errorf("YAML Request Body NOT supported by RequestType %s", method)
+	}
+	if r.BearerToken != "" && r.BearerTokenFile != "" {
+		return errors.New("YAML Request BearerToken excludes BearerTokenFile")
+	}
+	if r.Username != "" && (r.BearerToken != "" || r.BearerTokenFile != "") {
+		return errors.New("YAML Request Username excludes bearer token")
+	}
+	if r.Password != "" && r.Username == "" {
+		return errors.New("YAML Request Password requires Username")
+	}
+	if r.BearerTokenFile != "" {
+		if _, err := r.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Token bearer token, BearerTokenFile is read on every call so rotated
+// tokens, e.g. service account tokens, are used, and scrubbed from logs
+func (r *Request) Token() (string, error) {
+	if r.BearerTokenFile == "" {
+		return r.BearerToken, nil
+	}
+	buf, err := ioutil.ReadFile(r.BearerTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("YAML Request BearerTokenFile %v", err)
+	}
+	token := strings.TrimSpace(string(buf))
+	addToken(r.BearerTokenFile, token)
+	return token, nil
+}
+
+// Apply set headers, Host and credentials of r on req
+func (r *Request) Apply(req *http.Request) error {
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+	if r.Host != "" {
+		req.Host = r.Host
+	}
+	if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	token, err := r.Token()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/timer"
+)
+
+type (
+	// Window period between From and To on Days, probe Interval applied within Windows
+	Window struct {
+		Days     string `yaml:"Days,omitempty"`
+		From     string `yaml:"From"`
+		To       string `yaml:"To"`
+		Interval int    `yaml:"Interval"`
+	}
+
+	// window parsed Window, times as offsets from midnight
+	window struct {
+		days     uint64
+		from, to time.Duration
+		interval time.Duration
+	}
+)
+
+// clockTime parse "15:04" into offset from midnight
+func clockTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("YAML Window time %q NOT HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether now falls within window, To before From wraps
+// midnight, To equal to From spans the whole day
+func (w window) contains(now time.Time) bool {
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+
+	if w.from == w.to {
+		return w.days&(1<<uint(now.Weekday())) != 0
+	}
+	if w.from < w.to {
+		return w.days&(1<<uint(now.Weekday())) != 0 && offset >= w.from && offset < w.to
+	}
+	if offset >= w.from {
+		return w.days&(1<<uint(now.Weekday())) != 0
+	}
+	yesterday := (now.Weekday() + 6) % 7
+	return offset < w.to && w.days&(1<<uint(yesterday)) != 0
+}
+
+// parseWindow parse Days, From and To of w
+func parseWindow(w Window) (window, error) {
+	days, err := timer.ParseDays(w.Days)
+	if err != nil {
+		return window{}, err
+	}
+	from, err := clockTime(w.From)
+	if err != nil {
+		return window{}, err
+	}
+	to, err := clockTime(w.To)
+	if err != nil {
+		return window{}, err
+	}
+	return window{
+		days:     days,
+		from:     from,
+		to:       to,
+		interval: time.Duration(w.Interval) * time.Second,
+	}, nil
+}
+
+// isScheduleNormalize parse Schedule, Windows and Maintenance
+func isScheduleNormalize(conf *Conf) error {
+	if conf.Env.Schedule != "" {
+		if len(conf.Env.Windows) > 0 || conf.Env.Once {
+			return errors.New("YAML Schedule excludes Windows and Once")
+		}
+		cron, err := timer.ParseCron(conf.Env.Schedule)
+		if err != nil {
+			return err
+		}
+		if cron.Next(time.Now()).IsZero() {
+			return errors.New("YAML Schedule never matches")
+		}
+		conf.cron = cron
+	}
+
+	conf.windows = nil
+	for _, w := range conf.Env.Windows {
+		if !(w.Interval >= 5 && w.Interval <= 300) {
+			return errors.New("YAML Window Interval out-of-range")
+		}
+		parsed, err := parseWindow(w)
+		if err != nil {
+			return err
+		}
+		conf.windows = append(conf.windows, parsed)
+	}
+
+	conf.maintenance = nil
+	for _, w := range conf.Env.Maintenance {
+		parsed, err := parseWindow(w)
+		if err != nil {
+			return err
+		}
+		conf.maintenance = append(conf.maintenance, parsed)
+	}
+	return nil
+}
+
+// intervalAt probe Interval in force at now, first matching Window wins
+func (c *Conf) intervalAt(now time.Time) time.Duration {
+	for _, w := range c.windows {
+		if w.contains(now) {
+			return w.interval
+		}
+	}
+	return time.Duration(c.Env.Interval) * time.Second
+}
+
+// InMaintenance reports whether now falls within a configured Maintenance window
+func (c *Conf) InMaintenance(now time.Time) bool {
+	for _, w := range c.maintenance {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next time of next normal probe run after now, implements timer.Schedule
+func (c *Conf) Next(now time.Time) time.Time {
+	if c.cron != nil {
+		splay := c.splay()
+		return c.cron.Next(now.Add(-splay)).Add(splay)
+	}
+	return now.Add(c.Jittered(c.intervalAt(now)))
+}
+
+// First time of first probe run after startup at now
+func (c *Conf) First(now time.Time) time.Time {
+	delay := time.Duration(c.Env.InitialDelay) * time.Second
+	switch {
+	case c.Env.Once:
+		if delay += c.splay(); delay < time.Second {
+			delay = time.Second
+		}
+		return now.Add(delay)
+	case c.cron != nil:
+		return c.Next(now.Add(delay))
+	}
+	return now.Add(delay + c.splay() + c.intervalAt(now))
+}
@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowContains(t *testing.T) {
+	// 2020-01-06 is a Monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	for _, c := range []struct {
+		name     string
+		w        Window
+		now      time.Time
+		contains bool
+	}{
+		{"within", Window{From: "08:00", To: "18:00"}, at(6, 12, 0), true},
+		{"at From", Window{From: "08:00", To: "18:00"}, at(6, 8, 0), true},
+		{"at To", Window{From: "08:00", To: "18:00"}, at(6, 18, 0), false},
+		{"before", Window{From: "08:00", To: "18:00"}, at(6, 7, 59), false},
+		{"weekday", Window{Days: "Mon-Fri", From: "08:00", To: "18:00"}, at(10, 12, 0), true},
+		{"weekend", Window{Days: "Mon-Fri", From: "08:00", To: "18:00"}, at(11, 12, 0), false},
+		{"wrap evening", Window{From: "22:00", To: "06:00"}, at(6, 23, 0), true},
+		{"wrap morning", Window{From: "22:00", To: "06:00"}, at(7, 5, 59), true},
+		{"wrap day", Window{From: "22:00", To: "06:00"}, at(7, 6, 0), false},
+		// morning belongs to the window started on the previous day
+		{"wrap from Friday", Window{Days: "Fri", From: "22:00", To: "06:00"}, at(11, 3, 0), true},
+		{"wrap into Friday", Window{Days: "Fri", From: "22:00", To: "06:00"}, at(10, 3, 0), false},
+		{"wrap Sunday night", Window{Days: "Sat-Sun", From: "22:00", To: "06:00"}, at(6, 3, 0), true},
+		{"whole day", Window{Days: "Mon", From: "00:00", To: "00:00"}, at(6, 23, 59), true},
+		{"whole day other", Window{Days: "Mon", From: "12:00", To: "12:00"}, at(7, 0, 0), false},
+	} {
+		w, err := parseWindow(c.w)
+		if err != nil {
+			t.Errorf("%s: parseWindow = %v", c.name, err)
+			continue
+		}
+		if got := w.contains(c.now); got != c.contains {
+			t.Errorf("%s: contains(%v) = %v, want %v", c.name, c.now, got, c.contains)
+		}
+	}
+}
+
+func TestParseWindowInvalid(t *testing.T) {
+	for _, w := range []Window{
+		{From: "8:00pm", To: "18:00"},
+		{From: "08:00", To: "24:00"},
+		{Days: "Mon-Foo", From: "08:00", To: "18:00"},
+	} {
+		if _, err := parseWindow(w); err == nil {
+			t.Errorf("parseWindow(%+v) = nil, want error", w)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// fileRef prefix of YAML values read from a secret file
+	fileRef = "file:"
+)
+
+var (
+	// envRef matches ${VAR} environment references within YAML values
+	envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	// tokensMutex guards tokens
+	tokensMutex sync.Mutex
+	// tokens last read from each token file at probe time, scrubbed as
+	// resolved references are
+	tokens = make(map[string]string)
+)
+
+// fieldName returns the YAML key of a struct field
+func fieldName(f reflect.StructField) string {
+	if tag := strings.Split(f.Tag.Get("yaml"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return f.Name
+}
+
+// walkStrings visits every string held by v, storing the value returned by fn
+func walkStrings(v reflect.Value, path string, fn func(path, s string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return walkStrings(v.Elem(), path, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			if err := walkStrings(v.Field(i), path+"."+fieldName(t.Field(i)), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			if err := walkStrings(e, fmt.Sprintf("%s.%v", path, k), fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, e)
+		}
+	case reflect.String:
+		s, err := fn(path, v.String())
+		if err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.SetString(s)
+		}
+	}
+	return nil
+}
+
+// resolve expands ${VAR} and file: references of a single YAML value,
+// errors never carry the resolved value. Values holding any reference are
+// secret, the resolved references are retained for Scrub.
+func (c *Conf) resolve(path, s string) (string, error) {
+	var err error
+	var refs []string
+	s = envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRef.FindStringSubmatch(ref)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("YAML %s variable ${%s} NOT defined", path, name)
+		}
+		refs = append(refs, val)
+		return val
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(s, fileRef) {
+		fName := strings.TrimPrefix(s, fileRef)
+		buf, err := ioutil.ReadFile(fName)
+		if err != nil {
+			return "", fmt.Errorf("YAML %s secret file %s unreadable", path, fName)
+		}
+		s = strings.TrimRight(string(buf), "\r\n")
+		refs = append(refs, s)
+	}
+
+	// literal values of secret keys, e.g. Password, are scrubbed as well
+	if refs == nil && s != "" && isSecretKey(path) {
+		refs = []string{s}
+	}
+	if refs != nil {
+		c.secrets[path] = refs
+	}
+	return s, nil
+}
+
+// addToken register token read from token file fName for Scrub
+func addToken(fName, token string) {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+	tokens[fName] = token
+}
+
+// Interpolate resolves environment and secret file references of conf
+func Interpolate(conf *Conf) error {
+	return walkStrings(reflect.ValueOf(&conf.Env), "Env", conf.resolve)
+}
+
+// IsSecret reports whether the value at path was resolved from a reference
+// or is held by a secret key
+func (c *Conf) IsSecret(path string) bool {
+	_, ok := c.secrets[path]
+	return ok
+}
+
+// Scrub replaces resolved reference values, secret key values and tokens
+// read from token files within s, e.g. an error message carrying a request
+// URL, before it is logged
+func (c *Conf) Scrub(s string) string {
+	for _, refs := range c.secrets {
+		for _, ref := range refs {
+			if ref != "" {
+				s = strings.Replace(s, ref, Redacted, -1)
+			}
+		}
+	}
+
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+	for _, token := range tokens {
+		if token != "" {
+			s = strings.Replace(s, token, Redacted, -1)
+		}
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package conf
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// jitterMutex guards jitter, math/rand sources are not goroutine safe
+	jitterMutex sync.Mutex
+	jitter      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	// hostname seeds deterministic per host splay
+	hostname, _ = os.Hostname()
+)
+
+// splay deterministic offset within Splay seconds of this host and probe
+func (c *Conf) splay() time.Duration {
+	if c.Env.Splay <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(hostname + "/" + c.Env.Name))
+	window := uint64(time.Duration(c.Env.Splay) * time.Second / time.Millisecond)
+	return time.Duration(h.Sum64()%window) * time.Millisecond
+}
+
+// Jittered d randomly varied by up to Jitter percent either way
+func (c *Conf) Jittered(d time.Duration) time.Duration {
+	return jittered(d, c.Env.Jitter)
+}
+
+// jittered d randomly varied by up to percent either way
+func jittered(d time.Duration, percent int) time.Duration {
+	if percent <= 0 || d <= 0 {
+		return d
+	}
+	spread := int64(d) * int64(percent) / 100
+
+	jitterMutex.Lock()
+	offset := jitter.Int63n(2*spread+1) - spread
+	jitterMutex.Unlock()
+	return d + time.Duration(offset)
+}
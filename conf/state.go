@@ -0,0 +1,341 @@
+package conf
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Status probe health as last observed
+	Status int
+
+	// outcome single check result
+	outcome struct {
+		at     time.Time
+		failed bool
+	}
+
+	// ring fixed capacity buffer of most recent outcomes
+	ring struct {
+		buf  []outcome
+		next int
+		size int
+	}
+
+	// State probe runtime state, shared by successive configurations of a probe across reloads
+	State struct {
+		mutex        sync.Mutex
+		retryCounter int
+		restartCount uint32
+		status       Status
+		successes    int         // consecutive successful checks
+		healthySince time.Time   // first of consecutive successful checks
+		changes      []time.Time // healthy/failing changes within flap window
+		flapping     bool
+		outcomes     ring
+		actionAt     time.Time     // action awaiting verification, zero when none
+		actionWait   time.Duration // recovery delay, backed off, following action
+		unrecovered  int           // consecutive actions not followed by recovery
+		retrySteps   int           // retry delays backed off since stable
+		waitSteps    int           // recovery delays backed off since stable
+		degraded     int           // consecutive degraded checks
+	}
+)
+
+const (
+	// StatusUnknown probe not yet run
+	StatusUnknown Status = iota
+	// StatusHealthy last check succeeded
+	StatusHealthy
+	// StatusFailing last check failed
+	StatusFailing
+	// StatusBlocked failing, action withheld while a dependency is failing
+	StatusBlocked
+	// StatusFlapping alternating between healthy and failing
+	StatusFlapping
+	// StatusDegraded last check succeeded slower than WarnLatency
+	StatusDegraded
+)
+
+// String status name
+func (s Status) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusFailing:
+		return "failing"
+	case StatusBlocked:
+		return "blocked"
+	case StatusFlapping:
+		return "flapping"
+	case StatusDegraded:
+		return "degraded"
+	}
+	return "unknown"
+}
+
+// IsFailing reports whether status is failing for any reason
+func (s Status) IsFailing() bool {
+	return s == StatusFailing || s == StatusBlocked || s == StatusFlapping
+}
+
+// ResetCounter zero retry counter
+func (s *State) ResetCounter() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retryCounter = 0
+}
+
+// IncCounter increment retry counter, return new value
+func (s *State) IncCounter() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retryCounter++
+	return s.retryCounter
+}
+
+// RetryCounter current retry counter
+func (s *State) RetryCounter() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.retryCounter
+}
+
+// IncRestartCount increment restart count, return new value
+func (s *State) IncRestartCount() uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.restartCount++
+	return s.restartCount
+}
+
+// RestartCount number of restarts issued by probe
+func (s *State) RestartCount() uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.restartCount
+}
+
+// IncSuccess increment consecutive success counter, return new value
+func (s *State) IncSuccess() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.successes++
+	return s.successes
+}
+
+// ResetSuccess zero consecutive success counter
+func (s *State) ResetSuccess() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.successes = 0
+	s.healthySince = time.Time{}
+}
+
+// HealthyFor time since first of consecutive successful checks, now
+// starts the run when none
+func (s *State) HealthyFor(now time.Time) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.healthySince.IsZero() {
+		s.healthySince = now
+	}
+	return now.Sub(s.healthySince)
+}
+
+// reported status, flapping overrides observed status, mutex held
+func (s *State) reported() Status {
+	if s.flapping {
+		return StatusFlapping
+	}
+	return s.status
+}
+
+// Status last reported probe health
+func (s *State) Status() Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.reported()
+}
+
+// SetStatus record status observed at now, return previous and new reported
+// status. Changes between healthy and failing are counted over window, once
+// flaps are reached the probe is reported flapping until a whole window
+// passes without change. Zero flaps disables flap detection.
+func (s *State) SetStatus(status Status, now time.Time, window time.Duration, flaps int) (Status, Status) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prev := s.reported()
+	if s.status != StatusUnknown && s.status.IsFailing() != status.IsFailing() {
+		s.changes = append(s.changes, now)
+	}
+	s.status = status
+
+	recent := s.changes[:0]
+	for _, t := range s.changes {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	s.changes = recent
+
+	switch {
+	case flaps <= 0:
+		s.flapping = false
+	case len(s.changes) >= flaps:
+		s.flapping = true
+	case len(s.changes) == 0:
+		s.flapping = false
+	}
+	return prev, s.reported()
+}
+
+// Flapping reports whether probe is held flapping
+func (s *State) Flapping() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.flapping
+}
+
+// add outcome overwriting oldest once capacity is reached
+func (r *ring) add(o outcome, capacity int) {
+	if len(r.buf) != capacity {
+		r.buf = make([]outcome, capacity)
+		r.next, r.size = 0, 0
+	}
+	r.buf[r.next] = o
+	r.next = (r.next + 1) % capacity
+	if r.size < capacity {
+		r.size++
+	}
+}
+
+// Record add check outcome at now, keeping the last capacity outcomes
+func (s *State) Record(failed bool, now time.Time, capacity int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if capacity > 0 {
+		s.outcomes.add(outcome{at: now, failed: failed}, capacity)
+	}
+}
+
+// Outcomes failed and total of the last n outcomes recorded at or after
+// since, n <= 0 counts every retained outcome.
+func (s *State) Outcomes(n int, since time.Time) (failed, total int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r := &s.outcomes
+	for i := 1; i <= r.size && (n <= 0 || i <= n); i++ {
+		o := r.buf[(r.next-i+len(r.buf))%len(r.buf)]
+		if o.at.Before(since) {
+			break
+		}
+		total++
+		if o.failed {
+			failed++
+		}
+	}
+	return
+}
+
+// ClearOutcomes forget recorded outcomes
+func (s *State) ClearOutcomes() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.outcomes = ring{}
+}
+
+// StartVerify begin verification of action taken at now, followed by
+// recovery delay wait
+func (s *State) StartVerify(now time.Time, wait time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.actionAt = now
+	s.actionWait = wait
+}
+
+// ActionWait recovery delay following action under verification
+func (s *State) ActionWait() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.actionWait
+}
+
+// Verifying time of action under verification, false when none
+func (s *State) Verifying() (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.actionAt, !s.actionAt.IsZero()
+}
+
+// EndVerify record outcome of action under verification, return
+// consecutive actions not followed by recovery
+func (s *State) EndVerify(recovered bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.actionAt = time.Time{}
+	if recovered {
+		s.unrecovered = 0
+	} else {
+		s.unrecovered++
+	}
+	return s.unrecovered
+}
+
+// Unrecovered consecutive actions not followed by recovery
+func (s *State) Unrecovered() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.unrecovered
+}
+
+// ClearUnrecovered zero unrecovered actions, return previous value
+func (s *State) ClearUnrecovered() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	n := s.unrecovered
+	s.unrecovered = 0
+	return n
+}
+
+// NextRetryStep backoff step of next retry delay
+func (s *State) NextRetryStep() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retrySteps++
+	return s.retrySteps - 1
+}
+
+// NextWaitStep backoff step of next recovery delay
+func (s *State) NextWaitStep() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.waitSteps++
+	return s.waitSteps - 1
+}
+
+// ResetBackoff restart retry and recovery delays from their base, false when not backed off
+func (s *State) ResetBackoff() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	backedOff := s.retrySteps+s.waitSteps > 0
+	s.retrySteps, s.waitSteps = 0, 0
+	return backedOff
+}
+
+// IncDegraded increment consecutive degraded counter, return new value
+func (s *State) IncDegraded() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.degraded++
+	return s.degraded
+}
+
+// ResetDegraded zero consecutive degraded counter
+func (s *State) ResetDegraded() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.degraded = 0
+}
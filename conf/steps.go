@@ -0,0 +1,172 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/epiphany-platform/health-monitor/assert"
+)
+
+type (
+	// Step single request of a multi-step http transaction
+	Step struct {
+		Name        string      `yaml:"Name"`
+		Path        string      `yaml:"Path,omitempty"` // resolved against probe target
+		RequestType string      `yaml:"RequestType,omitempty"`
+		Request     Request     `yaml:"Request,omitempty"`
+		Assert      assert.Spec `yaml:"Assert,omitempty"`
+		Capture     []Capture   `yaml:"Capture,omitempty"`
+		assertion   *assert.Assertion
+	}
+
+	// Capture value of a step response made available to later steps as {{Name}}
+	Capture struct {
+		Name   string `yaml:"Name"`
+		Cookie string `yaml:"Cookie,omitempty"` // cookie name
+		Header string `yaml:"Header,omitempty"` // header name
+		JSON   string `yaml:"JSON,omitempty"`   // JSON path of body, e.g. $.token
+		path   *assert.Path
+	}
+)
+
+var (
+	// placeholderRE {{name}} reference to a captured value
+	placeholderRE = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// Substitute replace {{name}} references within s by captured values
+func Substitute(s string, values map[string]string) (string, error) {
+	var err error
+	out := placeholderRE.ReplaceAllStringFunc(s, func(ref string) string {
+		name := placeholderRE.FindStringSubmatch(ref)[1]
+		value, ok := values[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("Capture %s NOT defined", name)
+		}
+		return value
+	})
+	return out, err
+}
+
+// references names referenced by {{name}} within string fields of v
+func references(v interface{}) []string {
+	var names []string
+	walkStrings(reflect.ValueOf(v), "", func(path, s string) (string, error) {
+		for _, m := range placeholderRE.FindAllStringSubmatch(s, -1) {
+			names = append(names, m[1])
+		}
+		return s, nil
+	})
+	return names
+}
+
+// isCaptureNormalize validate capture has a name and exactly one source
+func isCaptureNormalize(capture *Capture) error {
+	if capture.Name == "" || placeholderRE.FindString("{{"+capture.Name+"}}") == "" {
+		return fmt.Errorf("YAML Capture Name %q invalid", capture.Name)
+	}
+	sources := 0
+	for _, source := range []string{capture.Cookie, capture.Header, capture.JSON} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("YAML Capture %s requires one of Cookie, Header or JSON", capture.Name)
+	}
+	if capture.JSON != "" {
+		path, err := assert.ParsePath(capture.JSON)
+		if err != nil {
+			return fmt.Errorf("YAML Capture %s %v", capture.Name, err)
+		}
+		capture.path = path
+	}
+	return nil
+}
+
+// isStepNormalize validate step, captured holds names captured by earlier steps
+func isStepNormalize(step *Step, captured map[string]bool) error {
+	if step.Name == "" {
+		return errors.New("YAML Step Name NOT defined")
+	}
+	for _, name := range references(step) {
+		if !captured[name] {
+			return fmt.Errorf("YAML Step %s references {{%s}} NOT captured by an earlier step", step.Name, name)
+		}
+	}
+
+	if step.Path == "" {
+		step.Path = "/"
+	}
+	ref, err := url.Parse(placeholderRE.ReplaceAllString(step.Path, "x"))
+	if err != nil || ref.IsAbs() || ref.Host != "" || !strings.HasPrefix(ref.Path, "/") {
+		return fmt.Errorf("YAML Step %s Path must be absolute path with optional query", step.Name)
+	}
+
+	assertion, err := assert.Compile(step.Assert, "")
+	if err != nil {
+		return fmt.Errorf("YAML Step %s %v", step.Name, err)
+	}
+	step.assertion = assertion
+
+	if step.RequestType == "" {
+		step.RequestType = "get"
+	}
+	method := strings.ToUpper(step.RequestType)
+	if !isRequestType(method) {
+		return fmt.Errorf("YAML Step %s RequestType %s NOT supported", step.Name, step.RequestType)
+	}
+	if method == http.MethodHead && (assertion.ReadsBody() || len(step.Capture) > 0) {
+		return fmt.Errorf("YAML Step %s Assert and Capture require a RequestType returning a body", step.Name)
+	}
+	if err := isRequestNormalize(method, &step.Request); err != nil {
+		return fmt.Errorf("YAML Step %s %v", step.Name, strings.TrimPrefix(err.Error(), "YAML "))
+	}
+
+	for i := range step.Capture {
+		if err := isCaptureNormalize(&step.Capture[i]); err != nil {
+			return err
+		}
+		captured[step.Capture[i].Name] = true
+	}
+	return nil
+}
+
+// isStepsNormalize validate transaction steps in order
+func isStepsNormalize(conf *Conf) error {
+	captured := make(map[string]bool)
+	for i := range conf.Env.Steps {
+		if err := isStepNormalize(&conf.Env.Steps[i], captured); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Assertion response assertions of step
+func (s *Step) Assertion() *assert.Assertion {
+	return s.assertion
+}
+
+// Path JSON path of capture, nil unless captured from JSON body
+func (c *Capture) Path() *assert.Path {
+	return c.path
+}
+
+// Substitute copy of r with {{name}} references replaced by captured values
+func (r Request) Substitute(values map[string]string) (Request, error) {
+	headers := make(map[string]string, len(r.Headers))
+	for name, value := range r.Headers {
+		headers[name] = value
+	}
+	r.Headers = headers
+	err := walkStrings(reflect.ValueOf(&r), "", func(path, s string) (string, error) {
+		return Substitute(s, values)
+	})
+	return r, err
+}
@@ -0,0 +1,237 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/epiphany-platform/health-monitor/assert"
+)
+
+const (
+	// RedirectNone redirects are not followed
+	RedirectNone = "none"
+	// RedirectSameHost redirects to the probed host are followed
+	RedirectSameHost = "same-host"
+	// RedirectAny redirects to any host are followed
+	RedirectAny = "any"
+
+	// ProxyNone requests are sent directly
+	ProxyNone = "none"
+	// ProxyEnvironment proxy taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	ProxyEnvironment = "environment"
+)
+
+var (
+	// hostnameRE RFC 1123 host name
+	hostnameRE = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*\.?$`)
+	// requestTypes HTTP methods accepted by RequestType
+	requestTypes = []string{http.MethodHead, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodOptions}
+)
+
+// isHost reports whether host is an IP literal or DNS host name
+func isHost(host string) bool {
+	return net.ParseIP(host) != nil || (len(host) <= 253 && hostnameRE.MatchString(host))
+}
+
+// defaultPort of URL scheme
+func defaultPort(scheme string) int {
+	if scheme == "https" || scheme == "wss" {
+		return 443
+	}
+	return 80
+}
+
+// isScheme reports whether scheme is one of schemes
+func isScheme(scheme string, schemes []string) bool {
+	for _, s := range schemes {
+		if scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTarget URL of probe, either URL or Scheme, IP, Port and Path, of
+// one of schemes. Path may carry a query string.
+func parseTarget(conf *Conf, schemes ...string) (*url.URL, error) {
+	if conf.Env.URL != "" {
+		target, err := url.Parse(conf.Env.URL)
+		if err != nil {
+			return nil, fmt.Errorf("YAML URL invalid: %v", err)
+		}
+		target.Scheme = strings.ToLower(target.Scheme)
+		if !isScheme(target.Scheme, schemes) {
+			return nil, fmt.Errorf("YAML URL scheme %s NOT supported", target.Scheme)
+		}
+		if !isHost(target.Hostname()) {
+			return nil, errors.New("YAML URL host invalid")
+		}
+		return target, nil
+	}
+
+	scheme := strings.ToLower(conf.Env.Scheme)
+	if !isScheme(scheme, schemes) {
+		return nil, fmt.Errorf("YAML Scheme %s NOT supported", conf.Env.Scheme)
+	}
+	conf.Env.Scheme = scheme
+	if !isHost(conf.Env.IP) {
+		return nil, errors.New("YAML IP address or host name out-of-bound")
+	}
+	if conf.Env.Port == 0 {
+		conf.Env.Port = defaultPort(scheme)
+	}
+	if !(conf.Env.Port >= 1 && conf.Env.Port <= 65535) {
+		return nil, errors.New("YAML Port out-of-range")
+	}
+	if conf.Env.Path == "" {
+		conf.Env.Path = "/"
+	}
+	ref, err := url.Parse(conf.Env.Path)
+	if err != nil || ref.IsAbs() || ref.Host != "" || !strings.HasPrefix(ref.Path, "/") {
+		return nil, errors.New("YAML Path must be absolute path with optional query")
+	}
+
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     net.JoinHostPort(conf.Env.IP, strconv.Itoa(conf.Env.Port)),
+		Path:     ref.Path,
+		RawPath:  ref.RawPath,
+		RawQuery: ref.RawQuery,
+	}, nil
+}
+
+// isNetworkNormalize validate proxy, source address and host overrides
+func isNetworkNormalize(conf *Conf) error {
+	if conf.Env.Proxy == "" {
+		conf.Env.Proxy = ProxyNone
+	}
+	if proxy := conf.Env.Proxy; proxy != ProxyNone && proxy != ProxyEnvironment {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return errors.New("YAML Proxy must be none, environment or a http, https or socks5 URL")
+		}
+		if conf.Env.Socket != "" {
+			return errors.New("YAML Proxy excludes Socket")
+		}
+	}
+
+	if conf.Env.SourceIP != "" && net.ParseIP(conf.Env.SourceIP) == nil {
+		return errors.New("YAML SourceIP invalid")
+	}
+	if conf.Env.SourceIP != "" && conf.Env.Interface != "" {
+		return errors.New("YAML SourceIP excludes Interface")
+	}
+	if conf.Env.Socket != "" && (conf.Env.SourceIP != "" || conf.Env.Interface != "" || len(conf.Env.Resolve) > 0) {
+		return errors.New("YAML Socket excludes SourceIP, Interface and Resolve")
+	}
+
+	for host, ip := range conf.Env.Resolve {
+		if !isHost(host) || net.ParseIP(ip) == nil {
+			return fmt.Errorf("YAML Resolve %s: %s invalid", host, ip)
+		}
+	}
+	return nil
+}
+
+// isHTTPNormalize resolve http probe target and request method
+func isHTTPNormalize(conf *Conf) error {
+	if !strings.EqualFold("http", conf.Env.Package) {
+		return nil
+	}
+
+	// URL carries its own scheme
+	if conf.Env.Scheme == "" && conf.Env.URL == "" {
+		conf.Env.Scheme = "http"
+	}
+	if conf.Env.Socket != "" {
+		if !filepath.IsAbs(conf.Env.Socket) {
+			return errors.New("YAML Socket must be absolute path")
+		}
+		// URL host only names the virtual host when dialing a socket
+		if conf.Env.IP == "" && conf.Env.URL == "" {
+			conf.Env.IP = "localhost"
+		}
+	}
+	target, err := parseTarget(conf, "http", "https")
+	if err != nil {
+		return err
+	}
+	conf.target = target
+
+	if conf.Env.Redirects == "" {
+		conf.Env.Redirects = RedirectSameHost
+	}
+	conf.Env.Redirects = strings.ToLower(conf.Env.Redirects)
+	if conf.Env.Redirects != RedirectNone && conf.Env.Redirects != RedirectSameHost && conf.Env.Redirects != RedirectAny {
+		return fmt.Errorf("YAML Redirects %s NOT supported", conf.Env.Redirects)
+	}
+	if conf.Env.MaxRedirects == 0 {
+		conf.Env.MaxRedirects = 10
+	}
+	if !(conf.Env.MaxRedirects >= 1 && conf.Env.MaxRedirects <= 50) {
+		return errors.New("YAML MaxRedirects out-of-range")
+	}
+
+	if err := isNetworkNormalize(conf); err != nil {
+		return err
+	}
+
+	if err := isTLSNormalize(conf); err != nil {
+		return err
+	}
+
+	assertion, err := assert.Compile(conf.Env.Assert, conf.Env.Response)
+	if err != nil {
+		return fmt.Errorf("YAML %v", err)
+	}
+	conf.assertion = assertion
+
+	if conf.Env.RequestType == "" {
+		conf.Env.RequestType = "head"
+		if assertion.ReadsBody() {
+			conf.Env.RequestType = "get"
+		}
+	}
+	method := strings.ToUpper(conf.Env.RequestType)
+	if !isRequestType(method) {
+		return fmt.Errorf("YAML RequestType %s NOT supported", conf.Env.RequestType)
+	}
+	if method == http.MethodHead && assertion.ReadsBody() {
+		return errors.New("YAML Assert Body and JSON require a RequestType returning a body")
+	}
+	if err := isRequestNormalize(method, &conf.Env.Request); err != nil {
+		return err
+	}
+	return isStepsNormalize(conf)
+}
+
+// isRequestType reports whether method is supported by http probes
+func isRequestType(method string) bool {
+	for _, m := range requestTypes {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Assertion response assertions of http probe
+func (c *Conf) Assertion() *assert.Assertion {
+	return c.assertion
+}
+
+// Target URL probed by http probe
+func (c *Conf) Target() *url.URL {
+	if c.target == nil {
+		return nil
+	}
+	target := *c.target
+	return &target
+}
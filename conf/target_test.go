@@ -0,0 +1,54 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/epiphany-platform/health-monitor/conf/conftest"
+)
+
+func TestTarget(t *testing.T) {
+	for _, c := range []struct {
+		name, pkg, extra string
+		target, scheme   string
+	}{
+		{"http default port", "http", "  IP: 10.0.0.1\n", "http://10.0.0.1:80/", "http"},
+		{"https default port", "http", "  Scheme: HTTPS\n  IP: node.example.com\n", "https://node.example.com:443/", "https"},
+		{"ipv6 literal", "http", "  Scheme: https\n  IP: fd00::1\n  Port: 6443\n  Path: /livez\n", "https://[fd00::1]:6443/livez", "https"},
+		{"path query", "http", "  IP: 127.0.0.1\n  Port: 10248\n  Path: /healthz?verbose=1\n", "http://127.0.0.1:10248/healthz?verbose=1", "http"},
+		{"url ipv6", "http", "  URL: https://[fd00::1]:6443/livez?verbose\n", "https://[fd00::1]:6443/livez?verbose", ""},
+		{"url without port", "http", "  URL: HTTPS://example.com/healthz\n", "https://example.com/healthz", ""},
+		{"url over ip port path", "http", "  URL: http://example.com:8080/ready\n  IP: 10.0.0.1\n  Port: 9090\n  Path: /healthz\n", "http://example.com:8080/ready", ""},
+		{"ws default port", "websocket", "  IP: 127.0.0.1\n  Path: /ws\n", "ws://127.0.0.1:80/ws", "ws"},
+		{"wss default port", "websocket", "  Scheme: wss\n  IP: 127.0.0.1\n", "wss://127.0.0.1:443/", "wss"},
+		{"wss url", "websocket", "  URL: wss://example.com/ws\n", "wss://example.com/ws", ""},
+	} {
+		err := Unmarshal(conftest.File(conftest.Probe("target", c.pkg, c.extra)))
+		if err != nil {
+			t.Errorf("%s: Unmarshal = %v", c.name, err)
+			continue
+		}
+		conf := Get("target")
+		if got := conf.Target().String(); got != c.target {
+			t.Errorf("%s: Target = %s, want %s", c.name, got, c.target)
+		}
+		if conf.Env.Scheme != c.scheme {
+			t.Errorf("%s: Scheme = %q, want %q", c.name, conf.Env.Scheme, c.scheme)
+		}
+	}
+}
+
+func TestTargetInvalid(t *testing.T) {
+	for name, extra := range map[string]string{
+		"url scheme":     "  URL: ftp://example.com/\n",
+		"url host":       "  URL: http://exa_mple.com/\n",
+		"scheme":         "  Scheme: ws\n  IP: 127.0.0.1\n",
+		"ip":             "  IP: not a host\n",
+		"port":           "  IP: 127.0.0.1\n  Port: 65536\n",
+		"relative path":  "  IP: 127.0.0.1\n  Path: healthz\n",
+		"path with host": "  IP: 127.0.0.1\n  Path: //example.com/healthz\n",
+	} {
+		if err := Unmarshal(conftest.File(conftest.Probe("target-"+name, "http", extra))); err == nil {
+			t.Errorf("%s: Unmarshal = nil, want error", name)
+		}
+	}
+}
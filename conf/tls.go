@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+type (
+	// TLS client TLS settings of a probe
+	TLS struct {
+		CAFile             string `yaml:"CAFile,omitempty"`
+		CertFile           string `yaml:"CertFile,omitempty"`
+		KeyFile            string `yaml:"KeyFile,omitempty"`
+		ServerName         string `yaml:"ServerName,omitempty"`
+		MinVersion         string `yaml:"MinVersion,omitempty"`
+		InsecureSkipVerify bool   `yaml:"InsecureSkipVerify,omitempty"`
+	}
+)
+
+var (
+	// tlsVersions TLS MinVersion values
+	tlsVersions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+)
+
+// isTLSNormalize validate TLS settings, loading CA bundle and client certificate once
+func isTLSNormalize(conf *Conf) error {
+	if conf.Env.TLS.MinVersion == "" {
+		conf.Env.TLS.MinVersion = "1.2"
+	}
+	if _, ok := tlsVersions[conf.Env.TLS.MinVersion]; !ok {
+		return fmt.Errorf("YAML TLS MinVersion %s NOT supported", conf.Env.TLS.MinVersion)
+	}
+	if (conf.Env.TLS.CertFile == "") != (conf.Env.TLS.KeyFile == "") {
+		return errors.New("YAML TLS CertFile and KeyFile must be set together")
+	}
+	if conf.Env.TLS.InsecureSkipVerify && conf.Env.TLS.CAFile != "" {
+		return errors.New("YAML TLS InsecureSkipVerify excludes CAFile")
+	}
+	_, err := conf.TLSConfig()
+	return err
+}
+
+// TLSConfig client TLS configuration of probe, verifying the server against
+// CAFile or system roots unless InsecureSkipVerify. The client certificate
+// is read on every handshake so rotated certificates are picked up.
+func (c *Conf) TLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         c.Env.TLS.ServerName,
+		MinVersion:         tlsVersions[c.Env.TLS.MinVersion],
+		InsecureSkipVerify: c.Env.TLS.InsecureSkipVerify,
+	}
+
+	if c.Env.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.Env.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("YAML TLS CAFile %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("YAML TLS CAFile %s holds no PEM certificates", c.Env.TLS.CAFile)
+		}
+	}
+
+	if c.Env.TLS.CertFile != "" {
+		certFile, keyFile := c.Env.TLS.CertFile, c.Env.TLS.KeyFile
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return nil, fmt.Errorf("YAML TLS CertFile or KeyFile %v", err)
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			return &cert, err
+		}
+	}
+	return config, nil
+}
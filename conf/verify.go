@@ -0,0 +1,41 @@
+package conf
+
+import (
+	"errors"
+	"time"
+)
+
+// isVerifyNormalize validate post action verification, defaults verify at
+// RetryDelay for as long as retries would have taken
+func isVerifyNormalize(conf *Conf) error {
+	if conf.Env.VerifyInterval == 0 {
+		conf.Env.VerifyInterval = conf.Env.RetryDelay
+	}
+	if !(conf.Env.VerifyInterval >= 1 && conf.Env.VerifyInterval <= 300) {
+		return errors.New("YAML VerifyInterval out-of-range")
+	}
+
+	if conf.Env.VerifyTimeout == 0 {
+		conf.Env.VerifyTimeout = conf.Env.Retries * conf.Env.RetryDelay
+	}
+	if !(conf.Env.VerifyTimeout >= conf.Env.VerifyInterval && conf.Env.VerifyTimeout <= 3600) {
+		return errors.New("YAML VerifyTimeout out-of-range")
+	}
+
+	if !(conf.Env.MaxUnrecovered >= 0 && conf.Env.MaxUnrecovered <= 10) {
+		return errors.New("YAML MaxUnrecovered out-of-range")
+	}
+	return nil
+}
+
+// VerifyDeadline end of verification of action taken at actionAt, the
+// recovery delay actually waited plus VerifyTimeout
+func (c *Conf) VerifyDeadline(actionAt time.Time) time.Time {
+	return actionAt.Add(c.ActionWait() + time.Duration(c.Env.VerifyTimeout)*time.Second)
+}
+
+// Escalated reports whether MaxUnrecovered consecutive actions failed to
+// recover the probe, further actions are withheld until it recovers
+func (c *Conf) Escalated() bool {
+	return c.Env.MaxUnrecovered > 0 && c.Unrecovered() >= c.Env.MaxUnrecovered
+}
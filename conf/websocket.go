@@ -0,0 +1,74 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type (
+	// WebSocket message exchanged by websocket probe once upgraded
+	WebSocket struct {
+		Send   string `yaml:"Send,omitempty"`   // text message sent after upgrade
+		Expect string `yaml:"Expect,omitempty"` // regular expression matched against reply
+	}
+)
+
+// isWebSocketNormalize resolve websocket probe target, network, TLS and
+// request headers. Connections are dialed as http probes dial them, but not
+// through a proxy.
+func isWebSocketNormalize(conf *Conf) error {
+	if !strings.EqualFold("websocket", conf.Env.Package) {
+		return nil
+	}
+
+	// URL carries its own scheme
+	if conf.Env.Scheme == "" && conf.Env.URL == "" {
+		conf.Env.Scheme = "ws"
+	}
+	if conf.Env.Socket != "" {
+		if !filepath.IsAbs(conf.Env.Socket) {
+			return errors.New("YAML Socket must be absolute path")
+		}
+		// URL host only names the virtual host when dialing a socket
+		if conf.Env.IP == "" && conf.Env.URL == "" {
+			conf.Env.IP = "localhost"
+		}
+	}
+	target, err := parseTarget(conf, "ws", "wss")
+	if err != nil {
+		return err
+	}
+	conf.target = target
+
+	if err := isNetworkNormalize(conf); err != nil {
+		return err
+	}
+	if conf.Env.Proxy != ProxyNone {
+		return errors.New("YAML Proxy NOT supported by websocket probes")
+	}
+
+	if err := isTLSNormalize(conf); err != nil {
+		return err
+	}
+	if err := isRequestNormalize(http.MethodGet, &conf.Env.Request); err != nil {
+		return err
+	}
+
+	if conf.Env.WebSocket.Expect != "" {
+		expect, err := regexp.Compile(conf.Env.WebSocket.Expect)
+		if err != nil {
+			return fmt.Errorf("YAML WebSocket Expect %v", err)
+		}
+		conf.expect = expect
+	}
+	return nil
+}
+
+// Expect pattern replies of websocket probe must match, nil accepts any reply
+func (c *Conf) Expect() *regexp.Regexp {
+	return c.expect
+}
@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"os/exec"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -16,8 +15,10 @@ import (
 	"github.com/epiphany-platform/health-monitor/conf"
 	"github.com/epiphany-platform/health-monitor/logger"
 	"github.com/epiphany-platform/health-monitor/metric"
+	"github.com/epiphany-platform/health-monitor/probe"
 	"github.com/epiphany-platform/health-monitor/timer"
 )
+
 // operationTimeout is the error returned when the docker operations are timeout.
 type operationTimeout struct {
 	err error
@@ -35,21 +36,19 @@ const (
 	dockerTimerWait = 2004
 )
 
-// recoveryDelayTimer initiate Recovery Delay timer allow service to recover
-func recoveryDelayTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.RecoveryDelay),
-		timer.Type(DockerTimerType),
-		timer.SubType(dockerTimerWait),
-		timer.User(conf),
-	)
-	logger.Info(
-		fmt.Sprintf("Service %s Probe Delayed %d secs, allowance recovery of resources.",
-			conf.Env.Name,
-			conf.Env.RecoveryDelay),
-	)
-}
+var (
+	// handler docker hooks of the shared probe state machine
+	handler = &probe.Handler{
+		Package:   dockerPackage,
+		Type:      DockerTimerType,
+		SubType:   dockerTimerSubtype,
+		Retry:     dockerTimerRetry,
+		Wait:      dockerTimerWait,
+		Check:     probeDocker,
+		Remediate: bounceService,
+		Metric:    metric.SetDockerMetric,
+	}
+)
 
 func dumpDockerDaemon(conf *conf.Conf) {
 	cmd := exec.Command("pkill", "-SIGUSR1", "docker")
@@ -69,12 +68,12 @@ func dumpDockerDaemon(conf *conf.Conf) {
 	}
 }
 
-func killDockerDaemon(conf *conf.Conf) {
+func killDockerDaemon(conf *conf.Conf) error {
 	cmd := exec.Command("systemctl", "kill", "--kill-who=main", "docker")
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	if err := cmd.Run(); err != nil {
-		logger.Err(err.Error())
+		return err
 	}
 	if len(out.String()) > 0 {
 		logger.Info(out.String())
@@ -85,74 +84,18 @@ func killDockerDaemon(conf *conf.Conf) {
 			conf.Env.Package,
 		))
 	}
+	return nil
 }
 
-// restartService running docker daemon
-func bounceService(conf *conf.Conf) {
-	if !conf.Env.ActionFatal {
-		resetCounter(conf)
-		recoveryDelayTimer(conf)
-	} else {
-		dumpDockerDaemon(conf)
-		killDockerDaemon(conf)
-		metric.IncrementRestartCount()
-		recoveryDelayTimer(conf)
-	}
+// bounceService dump stack of, then kill running docker daemon
+func bounceService(conf *conf.Conf) error {
+	dumpDockerDaemon(conf)
+	return killDockerDaemon(conf)
 }
 
 // Run launch specified client
 func Run() {
-	conf.Run(
-		dockerPackage,
-		DockerTimerType,
-		dockerTimerSubtype,
-	)
-}
-
-// retryServiceTimer initiates timer to retry probe
-func retryServiceTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.RetryDelay),
-		timer.Type(DockerTimerType),
-		timer.SubType(dockerTimerRetry),
-		timer.User(conf),
-	)
-	logger.Info(fmt.Sprintf(
-		"Retrying Probe %s Service %s attempts Cur: %d Max: %d",
-		conf.Env.Name,
-		conf.Env.Package,
-		conf.RetryCounter,
-		conf.Env.Retries,
-	))
-}
-
-// retryOperation retry operation n times
-func restartService(conf *conf.Conf) {
-	if incCounter(conf) <= conf.Env.Retries {
-		retryServiceTimer(conf)
-	} else {
-		logger.Warning(fmt.Sprintf(
-			"Bouncing %s Service %s Exceeded Retry attempts Cur: %d Max: %d",
-			conf.Env.Name,
-			conf.Env.Package,
-			conf.RetryCounter,
-			conf.Env.Retries,
-		))
-		resetCounter(conf)
-		bounceService(conf)
-	}
-}
-
-// armTimer launch default Docker timer
-func armTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.Interval),
-		timer.Type(DockerTimerType),
-		timer.SubType(dockerTimerSubtype),
-		timer.User(conf),
-	)
+	handler.Run()
 }
 
 // EncodeURL format URL components to facilitate connection
@@ -167,6 +110,11 @@ func (e operationTimeout) Error() string {
 	return fmt.Sprintf("operation timeout: %v", e.err)
 }
 
+// Timeout classifies operationTimeout as failure.Timeout
+func (e operationTimeout) Timeout() bool {
+	return true
+}
+
 // contextError checks the context, and returns error if the context is timeout.
 func contextError(ctx context.Context) error {
 	if ctx.Err() == context.DeadlineExceeded {
@@ -181,6 +129,7 @@ func probeDocker(conf *conf.Conf) error {
 	if err != nil {
 		return err
 	}
+	defer cli.Close()
 
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(conf.Env.ProtocolTimeout) * time.Second)
@@ -191,38 +140,10 @@ func probeDocker(conf *conf.Conf) error {
 	if ctxErr := contextError(ctx); ctxErr != nil {
 		return ctxErr
 	}
-
-
-	cli.Close()
 	return err
 }
 
-// resetCounter retry counter
-func resetCounter(conf *conf.Conf) {
-	conf.RetryCounter = 0
-}
-
-// incCounter increment retry counter
-func incCounter(conf *conf.Conf) int {
-	conf.RetryCounter++
-	return conf.RetryCounter
-}
-
 // Probe specified docker HTTP endpoint
 func Probe(tle *timer.TLE) {
-	conf, _ := tle.User.(*conf.Conf)
-	if err := probeDocker(conf); err == nil {
-		metric.SetDockerMetric(1)
-		resetCounter(conf)
-		armTimer(conf)
-	} else {
-		metric.SetDockerMetric(0)
-		if !strings.Contains(
-			strings.ToLower(err.Error()), "cannot connect to the docker daemon") {
-			logger.Warning(err.Error())
-			restartService(conf)
-		} else {
-			armTimer(conf)
-		}
-	}
+	handler.Probe(tle)
 }
@@ -0,0 +1,159 @@
+package failure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type (
+	// Class category of probe error
+	Class string
+
+	// Action taken on errors of a class
+	Action string
+
+	// StatusError response status not matching expected response
+	StatusError struct {
+		Status string
+	}
+
+	// BodyError response body failing an assertion
+	BodyError struct {
+		Reason string
+	}
+
+	// LatencyError successful check slower than critical latency
+	LatencyError struct {
+		Latency, Limit time.Duration
+	}
+
+	// timeout implemented by errors reporting a timeout
+	timeout interface {
+		Timeout() bool
+	}
+)
+
+const (
+	// Refused connection refused or daemon socket unreachable
+	Refused Class = "refused"
+	// Timeout no response within ProtocolTimeout
+	Timeout Class = "timeout"
+	// DNS name resolution failed
+	DNS Class = "dns"
+	// TLS handshake or certificate verification failed
+	TLS Class = "tls"
+	// Status unexpected response status
+	Status Class = "status"
+	// Body response body assertion failed
+	Body Class = "body"
+	// Other any other error
+	Other Class = "other"
+
+	// Fail count error as a failed probe, default
+	Fail Action = "fail"
+	// Ignore re-arm probe without counting error
+	Ignore Action = "ignore"
+	// Remediate take action immediately without retries
+	Remediate Action = "remediate"
+)
+
+var (
+	// Classes every error class
+	Classes = []Class{Refused, Timeout, DNS, TLS, Status, Body, Other}
+	// Actions every error action
+	Actions = []Action{Fail, Ignore, Remediate}
+)
+
+// Error response status
+func (e *StatusError) Error() string {
+	return "Response NOT matching failure: " + e.Status
+}
+
+// Error body assertion failure
+func (e *BodyError) Error() string {
+	return "Response body NOT matching failure: " + e.Reason
+}
+
+// Error latency exceeded
+func (e *LatencyError) Error() string {
+	return fmt.Sprintf("Latency %v exceeding critical %v", e.Latency, e.Limit)
+}
+
+// Timeout classifies LatencyError as Timeout
+func (e *LatencyError) Timeout() bool {
+	return true
+}
+
+// ParseClass validate class name
+func ParseClass(s string) (Class, error) {
+	for _, c := range Classes {
+		if strings.EqualFold(s, string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("Error class %s NOT defined", s)
+}
+
+// ParseAction validate action name
+func ParseAction(s string) (Action, error) {
+	for _, a := range Actions {
+		if strings.EqualFold(s, string(a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("Error action %s NOT defined", s)
+}
+
+// Classify category of probe error
+func Classify(err error) Class {
+	var (
+		statusErr *StatusError
+		bodyErr   *BodyError
+		dnsErr    *net.DNSError
+		recordErr tls.RecordHeaderError
+		authErr   x509.UnknownAuthorityError
+		hostErr   x509.HostnameError
+		certErr   x509.CertificateInvalidError
+		timeErr   timeout
+	)
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &statusErr):
+		return Status
+	case errors.As(err, &bodyErr):
+		return Body
+	case errors.As(err, &dnsErr):
+		return DNS
+	case errors.As(err, &recordErr), errors.As(err, &authErr),
+		errors.As(err, &hostErr), errors.As(err, &certErr):
+		return TLS
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ENOENT):
+		return Refused
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &timeErr) && timeErr.Timeout():
+		return Timeout
+	}
+
+	text := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(text, "connection refused"),
+		strings.Contains(text, "cannot connect to the docker daemon"):
+		return Refused
+	case strings.Contains(text, "timeout"), strings.Contains(text, "deadline exceeded"):
+		return Timeout
+	case strings.Contains(text, "no such host"):
+		return DNS
+	case strings.Contains(text, "tls:"), strings.Contains(text, "x509:"):
+		return TLS
+	}
+	return Other
+}
@@ -0,0 +1,84 @@
+package failure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// refused dial error of a closed local port
+func refused(t *testing.T) error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	conn, err := net.Dial("tcp", addr)
+	if err == nil {
+		conn.Close()
+		t.Skip("closed port accepted connection")
+	}
+	return err
+}
+
+// timedOut dial error of an expired context
+func timedOut() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	var d net.Dialer
+	_, err := d.DialContext(ctx, "tcp", "127.0.0.1:1")
+	return err
+}
+
+func TestClassify(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, ""},
+		{"refused", refused(t), Refused},
+		{"refused url", &url.Error{Op: "Get", URL: "http://127.0.0.1:1", Err: refused(t)}, Refused},
+		{"refused text", errors.New("dial tcp 10.0.0.1:80: connect: connection refused"), Refused},
+		{"docker", errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?"), Refused},
+		{"timeout", timedOut(), Timeout},
+		{"deadline", fmt.Errorf("list containers: %w", context.DeadlineExceeded), Timeout},
+		{"latency", &LatencyError{Latency: 3 * time.Second, Limit: time.Second}, Timeout},
+		{"timeout text", errors.New("net/http: request canceled (Client.Timeout exceeded while awaiting headers)"), Timeout},
+		{"dns", &url.Error{Op: "Get", URL: "http://x.invalid", Err: &net.DNSError{Err: "no such host", Name: "x.invalid"}}, DNS},
+		{"tls authority", &url.Error{Op: "Get", URL: "https://x", Err: x509.UnknownAuthorityError{}}, TLS},
+		{"tls hostname", x509.HostnameError{Certificate: &x509.Certificate{}, Host: "x"}, TLS},
+		{"tls record", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, TLS},
+		{"tls text", errors.New("remote error: tls: bad certificate"), TLS},
+		{"status", fmt.Errorf("step login: %w", &StatusError{Status: "503 Service Unavailable"}), Status},
+		{"body", &BodyError{Reason: "$.status == \"ok\""}, Body},
+		{"other", errors.New("unexpected EOF"), Other},
+	} {
+		if got := Classify(c.err); got != c.want {
+			t.Errorf("%s: Classify(%v) = %q, want %q", c.name, c.err, got, c.want)
+		}
+	}
+}
+
+func TestParseClassAction(t *testing.T) {
+	if c, err := ParseClass("DNS"); err != nil || c != DNS {
+		t.Errorf("ParseClass(DNS) = %q %v", c, err)
+	}
+	if _, err := ParseClass("refusal"); err == nil {
+		t.Error("ParseClass(refusal) = nil error")
+	}
+	if a, err := ParseAction("Ignore"); err != nil || a != Ignore {
+		t.Errorf("ParseAction(Ignore) = %q %v", a, err)
+	}
+	if _, err := ParseAction("restart"); err == nil {
+		t.Error("ParseAction(restart) = nil error")
+	}
+}
@@ -2,11 +2,12 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/epiphany-platform/health-monitor/channel"
+	"github.com/epiphany-platform/health-monitor/api"
 	"github.com/epiphany-platform/health-monitor/conf"
 	"github.com/epiphany-platform/health-monitor/docker"
 	"github.com/epiphany-platform/health-monitor/http"
@@ -14,6 +15,8 @@ import (
 	"github.com/epiphany-platform/health-monitor/metric"
 	daemon "github.com/epiphany-platform/health-monitor/notify"
 	"github.com/epiphany-platform/health-monitor/timer"
+	"github.com/epiphany-platform/health-monitor/websocket"
+	"github.com/epiphany-platform/health-monitor/worker"
 )
 
 const (
@@ -21,15 +24,55 @@ const (
 	watchdogName    = "Watchdog"
 	watchdogType    = 1001
 	watchdogSubtype = 1002
+	// dispatchDelay seconds before re-dispatching a probe refused by saturated workers
+	dispatchDelay = 1
+	// workerDepth probe jobs queued per worker
+	workerDepth = 4
 )
 
 var (
 	// health liveness configuration file
-	healthdConf = flag.String("-c", "healthd.yml", "YAML configuation file")
+	healthdConf = flag.String("c", "healthd.yml", "YAML configuation file")
 	// health liveness prometheus port #
-	healthdPort = flag.String("-p", "2112", "Prometheus IP port #")
+	healthdPort = flag.String("p", "2112", "Prometheus IP port #")
+	// bearer token file authorizing maintenance API changes
+	healthdToken = flag.String("t", "", "API token file authorizing maintenance changes, loopback only when unset")
+	// config dump output format
+	healthdFormat = flag.String("o", "yaml", "config dump format yaml or json")
+	// probe and remediation worker pool size
+	healthdWorkers = flag.Int("w", 8, "Probe worker pool size")
+	// workers run probes off the timer loop, one job at a time per probe
+	workers *worker.Pool
 )
 
+// configCommand handles "healthd config dump", returns process exit code
+func configCommand(args []string) int {
+	if len(args) != 1 || args[0] != "dump" {
+		fmt.Fprintln(os.Stderr, "usage: healthd [-c healthd.yml] [-o yaml|json] config dump")
+		return 2
+	}
+	if err := logger.Init(); err != nil {
+		panic(err)
+	}
+	if err := conf.Load(*healthdConf); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := conf.Dump(os.Stdout, *healthdFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Parse command line, config subcommands run and exit before daemon startup
+func init() {
+	flag.Parse()
+	if flag.Arg(0) == "config" {
+		os.Exit(configCommand(flag.Args()[1:]))
+	}
+}
+
 // Notify systemd startup ok
 func init() {
 	if ok, err := daemon.SdNotify(false, daemon.SdNotifyReady); !ok {
@@ -53,6 +96,11 @@ func init() {
 	}
 }
 
+// Start probe worker pool
+func init() {
+	workers = worker.New(*healthdWorkers, *healthdWorkers*workerDepth)
+}
+
 // Setup watch watchdog timer
 func init() {
 	interval, err := daemon.SdWatchdogEnabled(false)
@@ -71,6 +119,11 @@ func init() {
 	metric.Run(healthdPort)
 }
 
+// Run API endpoints
+func init() {
+	api.Run(*healthdToken)
+}
+
 // Run Docker Probes
 func init() {
 	docker.Run()
@@ -81,6 +134,11 @@ func init() {
 	http.Run()
 }
 
+// Run WebSocket Probes
+func init() {
+	websocket.Run()
+}
+
 // daemonSignals catch specific signals
 func init() {
 	daemonChan := make(chan os.Signal, 1)
@@ -98,9 +156,9 @@ func init() {
 			case syscall.SIGHUP:
 				{
 					daemon.SdNotify(false, daemon.SdNotifyReloading)
+					// invalid configuration is reported, the current one kept running
 					if err := conf.Load(*healthdConf); err != nil {
-						logger.Err(err.Error())
-						panic(err)
+						logger.Err(fmt.Sprintf("Reload of %s failed, configuration kept: %v", *healthdConf, err))
 					}
 					daemon.SdNotify(false, daemon.SdNotifyReady)
 				}
@@ -144,6 +202,26 @@ func watchDog(tle *timer.TLE) {
 	}
 }
 
+// dispatch run probe on worker pool, serialized per probe name
+func dispatch(tle *timer.TLE, probe func(*timer.TLE)) {
+	if workers.Submit(tle.Name, func() { probe(tle) }) {
+		return
+	}
+	logger.Warning(fmt.Sprintf(
+		"Probe %s deferred %d secs, workers saturated.",
+		tle.Name,
+		dispatchDelay,
+	))
+	timer.Launch(
+		timer.Name(tle.Name),
+		timer.Timeout(dispatchDelay),
+		timer.Type(tle.Type),
+		timer.SubType(tle.SubType),
+		timer.Key(tle.Key),
+		timer.User(tle.User),
+	)
+}
+
 // Orchestrate timer Completions
 func orchestrate(tle *timer.TLE) {
 	switch tle.Type {
@@ -153,33 +231,30 @@ func orchestrate(tle *timer.TLE) {
 		}
 	case docker.DockerTimerType:
 		{
-			docker.Probe(tle)
+			dispatch(tle, docker.Probe)
 		}
 	case http.HTTPTimerType:
 		{
-			http.Probe(tle)
+			dispatch(tle, http.Probe)
+		}
+	case websocket.WebSocketTimerType:
+		{
+			dispatch(tle, websocket.Probe)
 		}
 	}
 }
 
-// WaitTimer channel timer completions
+// WaitTimer channel timer completions. Once no timer is running, e.g. every
+// probe ran Once, wait for reload rather than exit and be restarted.
 func waitTimerCompletions() {
-	for channel.Active() {
-		_, Chosen, message, _ := channel.Awaitio()
-		switch message.Type().String() {
-		case timer.Completion:
-			{
-				if tle, ok := message.Interface().(*timer.TLE); ok {
-					orchestrate(tle)
-				}
-				channel.Remove(Chosen)
-			}
+	for {
+		if !timer.Active() && !workers.Busy() {
+			logger.Info("No timer(s) are running, awaiting configuration reload.")
 		}
+		orchestrate(timer.Await())
 	}
-	logger.Err("Internal logic error, No timer(s) are running.")
 }
 
 func main() {
 	waitTimerCompletions()
-	os.Exit(1)
 }
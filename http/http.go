@@ -3,8 +3,8 @@ package http
 import (
 	"bytes"
 	"crypto/tls"
-	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -12,11 +12,14 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/epiphany-platform/health-monitor/assert"
 	"github.com/epiphany-platform/health-monitor/conf"
 	"github.com/epiphany-platform/health-monitor/logger"
 	"github.com/epiphany-platform/health-monitor/metric"
+	"github.com/epiphany-platform/health-monitor/probe"
 	"github.com/epiphany-platform/health-monitor/timer"
 	"golang.org/x/net/http2"
 )
@@ -24,13 +27,24 @@ import (
 var (
 	defaultTransport        = http.DefaultTransport.(*http.Transport)
 	defaultProxyFuncPointer = fmt.Sprintf("%p", http.ProxyFromEnvironment)
+
+	// probersMutex guards probers
+	probersMutex sync.Mutex
+	// probers transport per probe name, rebuilt once configuration is reloaded
+	probers = make(map[string]prober)
 )
 
 type (
 	// ProbeHTTP Transport
 	ProbeHTTP struct {
-		transport               *http.Transport
-		followNonLocalRedirects bool
+		transport     *http.Transport
+		checkRedirect func(*http.Request, []*http.Request) error
+	}
+
+	// prober ProbeHTTP built for a loaded probe configuration
+	prober struct {
+		conf  *conf.Conf
+		probe ProbeHTTP
 	}
 )
 
@@ -46,6 +60,20 @@ const (
 	httpTimerWait = 3004
 )
 
+var (
+	// handler http hooks of the shared probe state machine
+	handler = &probe.Handler{
+		Package:   httpPackage,
+		Type:      HTTPTimerType,
+		SubType:   httpTimerSubtype,
+		Retry:     httpTimerRetry,
+		Wait:      httpTimerWait,
+		Check:     check,
+		Remediate: bounceService,
+		Metric:    metric.SetKubeletMetric,
+	}
+)
+
 // EncodeURL format URL components to facilitate connection
 func EncodeURL(scheme string, host string, port int, path string) *url.URL {
 	return &url.URL{
@@ -140,21 +168,35 @@ func SetTransportDefaults(t *http.Transport) *http.Transport {
 	return t
 }
 
-// New creates Prober that will skip TLS verification while probing.
+// New creates Prober verifying TLS against system roots while probing.
 func New(followNonLocalRedirects bool) ProbeHTTP {
-	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
 	return NewWithTLSConfig(tlsConfig, followNonLocalRedirects)
 }
 
 // NewWithTLSConfig takes tls config as parameter.
 func NewWithTLSConfig(config *tls.Config, followNonLocalRedirects bool) ProbeHTTP {
+	// every check opens its own connection, as the kubelet prober does, so a
+	// wedged listener or expired server certificate is not masked by a
+	// kept-alive connection. Reuse saves building transport, TLS and HTTP/2
+	// configuration on every run.
 	transport := SetTransportDefaults(
 		&http.Transport{
 			TLSClientConfig:   config,
 			DisableKeepAlives: true,
 			Proxy:             http.ProxyURL(nil),
 		})
-	return ProbeHTTP{transport, followNonLocalRedirects}
+	p := ProbeHTTP{transport: transport}
+	if followNonLocalRedirects {
+		return p.WithRedirects(conf.RedirectAny, 10)
+	}
+	return p.WithRedirects(conf.RedirectSameHost, 10)
+}
+
+// WithRedirects follow redirects by policy, none, same-host or any, up to max hops
+func (p ProbeHTTP) WithRedirects(policy string, max int) ProbeHTTP {
+	p.checkRedirect = redirects(policy, max)
+	return p
 }
 
 // Method interface for making HTTP requests
@@ -162,155 +204,96 @@ type Method interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// recoveryDelayTimer initiate Recovery Delay timer allow service to recover
-func recoveryDelayTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.RecoveryDelay),
-		timer.Type(HTTPTimerType),
-		timer.SubType(httpTimerWait),
-		timer.User(conf),
-	)
-	logger.Info(
-		fmt.Sprintf("Service %s Probe Delayed %d secs, allowance recovery of resources.",
-			conf.Env.Name,
-			conf.Env.RecoveryDelay),
-	)
-}
-
 // bounceService running http daemon
-func bounceService(conf *conf.Conf) {
-	if !conf.Env.ActionFatal {
-		resetCounter(conf)
-		recoveryDelayTimer(conf)
-	} else {
-		cmd := exec.Command("systemctl", "restart", "kubelet")
-		var out bytes.Buffer
-		cmd.Stdout = &out
-		err := cmd.Run()
-		if err != nil {
-			logger.Err(err.Error())
-			panic(err)
-		} else {
-			if len(out.String()) > 0 {
-				logger.Info(out.String())
-			} else {
-				logger.Info(fmt.Sprintf(
-					"Restarted Name: %s Service: %s Completed",
-					conf.Env.Name,
-					conf.Env.Package,
-				))
-			}
-			metric.IncrementRestartCount()
-			recoveryDelayTimer(conf)
-		}
+func bounceService(conf *conf.Conf) error {
+	cmd := exec.Command("systemctl", "restart", "kubelet")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return err
 	}
-}
-
-// retryServiceTimer initiates timer to retry probe
-func retryServiceTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.Interval),
-		timer.Type(HTTPTimerType),
-		timer.SubType(httpTimerRetry),
-		timer.User(conf),
-	)
-	logger.Info(fmt.Sprintf(
-		"Retrying Probe %s Service %s attempts Cur: %d Max: %d",
-		conf.Env.Name,
-		conf.Env.Package,
-		conf.RetryCounter,
-		conf.Env.Retries,
-	))
-}
-
-// restartService Check whether service needs restarting
-func restartService(conf *conf.Conf) {
-	if incCounter(conf) <= conf.Env.Retries {
-		retryServiceTimer(conf)
+	if len(out.String()) > 0 {
+		logger.Info(out.String())
 	} else {
-		logger.Warning(fmt.Sprintf(
-			"Bouncing %s Service %s Exceeded Retry attempts Cur: %d Max: %d",
+		logger.Info(fmt.Sprintf(
+			"Restarted Name: %s Service: %s Completed",
 			conf.Env.Name,
 			conf.Env.Package,
-			conf.RetryCounter,
-			conf.Env.Retries,
 		))
-		resetCounter(conf)
-		bounceService(conf)
 	}
+	return nil
 }
 
-func armTimer(conf *conf.Conf) {
-	timer.Launch(
-		timer.Name(conf.Env.Name),
-		timer.Timeout(conf.Env.Interval),
-		timer.Type(HTTPTimerType),
-		timer.SubType(httpTimerSubtype),
-		timer.User(conf),
-	)
-}
-
-// Client create and initiate HTTP check.
+// Client create and initiate HTTP check, a transaction when Steps are configured.
 func (p ProbeHTTP) Client(conf *conf.Conf) (err error) {
+	if len(conf.Env.Steps) > 0 {
+		return p.Transaction(conf)
+	}
 	err = ProbeURL(
-		EncodeURL(
-			strings.ToUpper(conf.Env.Package),
-			conf.Env.IP,
-			conf.Env.Port,
-			conf.Env.Path),
-		strings.ToLower(conf.Env.Package),
+		conf.Target(),
+		strings.ToUpper(conf.Env.RequestType),
 		&http.Client{
 			Timeout:       time.Duration(conf.Env.ProtocolTimeout) * time.Second,
 			Transport:     p.transport,
-			CheckRedirect: redirects(p.followNonLocalRedirects),
+			CheckRedirect: p.checkRedirect,
 		},
-		conf.Env.Response,
+		&conf.Env.Request,
+		conf.Assertion(),
 	)
 	return
 }
 
-// ProbeURL checks whether http method to the url succeeds.
-func ProbeURL(url *url.URL, method string, client Method, response string) error {
-	req, err := http.NewRequest(method, url.String(), nil)
+// ProbeURL checks whether http method to the url, sent with headers, body
+// and credentials of request, succeeds and its response satisfies assertion.
+func ProbeURL(url *url.URL, method string, client Method, request *conf.Request, assertion *assert.Assertion) error {
+	_, _, err := send(url, method, client, request, assertion)
+	if err == nil {
+		metric.SetKubeletMetric(1)
+	}
+	return err
+}
+
+// send request, return response and body once the response satisfies assertion
+func send(url *url.URL, method string, client Method, request *conf.Request, assertion *assert.Assertion) (*http.Response, []byte, error) {
+	var body io.Reader
+	if request.Body != "" {
+		body = strings.NewReader(request.Body)
+	}
+	// errors carrying the url are logged scrubbed by the probe state machine
+	req, err := http.NewRequest(method, url.String(), body)
 	if err != nil {
+		return nil, nil, err
+	}
+	if err := request.Apply(req); err != nil {
 		logger.Info(err.Error())
-		return err
+		return nil, nil, err
 	}
 
 	res, err := client.Do(req)
 	if err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "connection refused") {
-			logger.Warning(err.Error())
-		}
-		return err
+		return nil, nil, err
 	}
 
 	defer res.Body.Close()
 
-	if !strings.Contains(strings.ToLower(res.Status), strings.ToLower(response)) {
-		err := fmt.Errorf("Response NOT matching failure: " + res.Status)
+	content, err := assertion.Check(res)
+	if err != nil {
 		logger.Info(err.Error())
-		return err
+		return nil, nil, err
 	}
-	metric.SetKubeletMetric(1)
-	return err
+	return res, content, nil
 }
 
-// redirects Follows non-local redirects
-func redirects(followRedirects bool) func(*http.Request, []*http.Request) error {
-	if followRedirects {
-		return nil
-	}
-
+// redirects Follows redirects permitted by policy up to max hops
+func redirects(policy string, max int) func(*http.Request, []*http.Request) error {
 	return func(req *http.Request, via []*http.Request) error {
-		if req.URL.Hostname() != via[0].URL.Hostname() {
+		if policy == conf.RedirectNone ||
+			(policy == conf.RedirectSameHost && req.URL.Hostname() != via[0].URL.Hostname()) {
 			logger.Info(http.ErrUseLastResponse.Error())
 			return http.ErrUseLastResponse
 		}
-		if len(via) >= 10 {
-			err := errors.New("stopped after 10 redirects")
+		if len(via) > max {
+			err := fmt.Errorf("stopped after %d redirects", max)
 			logger.Warning(err.Error())
 			return err
 		}
@@ -320,44 +303,66 @@ func redirects(followRedirects bool) func(*http.Request, []*http.Request) error
 
 // Run launch specified client timer(s)
 func Run() {
-	conf.Run(
-		httpPackage,
-		HTTPTimerType,
-		httpTimerSubtype,
-	)
+	handler.Run()
 }
 
-func resetCounter(conf *conf.Conf) {
-	conf.RetryCounter = 0
+// release drop transport of probes no longer configured as http probes,
+// probersMutex held
+func release() {
+	for name, cached := range probers {
+		if c := conf.Get(name); c == nil || !strings.EqualFold(c.Env.Package, httpPackage) {
+			cached.probe.transport.CloseIdleConnections()
+			delete(probers, name)
+		}
+	}
 }
 
-func incCounter(conf *conf.Conf) int {
-	conf.RetryCounter++
-	return conf.RetryCounter
+// proberOf transport of probe, built once per loaded configuration. A
+// reloaded configuration replaces the transport of the previous one and
+// releases those of removed probes.
+func proberOf(conf *conf.Conf) (ProbeHTTP, error) {
+	probersMutex.Lock()
+	defer probersMutex.Unlock()
+
+	cached, ok := probers[conf.Env.Name]
+	if ok && cached.conf == conf {
+		return cached.probe, nil
+	}
+	if ok {
+		cached.probe.transport.CloseIdleConnections()
+	}
+	release()
+
+	tlsConfig, err := conf.TLSConfig()
+	if err != nil {
+		return ProbeHTTP{}, err
+	}
+	p, err := NewWithTLSConfig(tlsConfig, false).
+		WithRedirects(conf.Env.Redirects, conf.Env.MaxRedirects).
+		WithProxy(conf.Env.Proxy)
+	if err != nil {
+		return ProbeHTTP{}, err
+	}
+	p.transport.DialContext = DialContext(conf)
+	probers[conf.Env.Name] = prober{conf: conf, probe: p}
+	return p, nil
 }
 
-// textedStatus check error text
-func textedStatus(err error) bool {
-	if strings.Contains(err.Error(), "connection refused") {
-		return true
+// check probe HTTP endpoint once
+func check(conf *conf.Conf) error {
+	p, err := proberOf(conf)
+	if err != nil {
+		return err
 	}
-	return false
+	return p.Client(conf)
 }
 
-// Probe specified HTTP endpoint
+// Probe specified HTTP endpoint, releasing transports once a probe is removed
 func Probe(tle *timer.TLE) {
-	conf, _ := tle.User.(*conf.Conf)	
-	p := New(false)
-	if err := p.Client(conf); err == nil {
-		metric.SetKubeletMetric(1)
-		resetCounter(conf)
-		armTimer(conf)
-	} else {
-		metric.SetKubeletMetric(0)
-		if !strings.Contains(err.Error(), "connection refused") {
-			restartService(conf)
-		} else {
-			armTimer(conf)
-		}
+	handler.Probe(tle)
+	if conf.Get(tle.Name) == nil {
+		probersMutex.Lock()
+		release()
+		probersMutex.Unlock()
 	}
 }
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/conf/conftest"
+)
+
+// probeYAML conf document of http probe name against url
+func probeYAML(name, url string) string {
+	return conftest.Probe(name, "http", "  URL: "+url+"\n")
+}
+
+// load Unmarshal conf documents failing tb on error
+func load(tb testing.TB, docs ...string) {
+	if err := conf.Unmarshal(conftest.File(docs...)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// healthz serve 200 on every request
+func healthz(tb testing.TB) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+func TestProberOfReusedUntilReload(t *testing.T) {
+	s := healthz(t)
+	load(t, probeYAML("a", s.URL), probeYAML("b", s.URL))
+
+	a, err := proberOf(conf.Get("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := proberOf(conf.Get("b")); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := proberOf(conf.Get("a")); again.transport != a.transport {
+		t.Error("transport rebuilt without reload")
+	}
+
+	load(t, probeYAML("a", s.URL))
+	if reloaded, _ := proberOf(conf.Get("a")); reloaded.transport == a.transport {
+		t.Error("transport NOT rebuilt on reload")
+	}
+	probersMutex.Lock()
+	_, ok := probers["b"]
+	probersMutex.Unlock()
+	if ok {
+		t.Error("transport of removed probe b retained")
+	}
+}
+
+func TestCheckSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "healthz.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "containerd:80" || r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+
+	load(t, conftest.Probe("socket", "http", "  Socket: "+socket+"\n  IP: containerd\n  Path: /healthz\n"))
+	if err := check(conf.Get("socket")); err != nil {
+		t.Errorf("check over Socket = %v", err)
+	}
+}
+
+// BenchmarkProbeCycle one probe run building its transport, as New(false)
+// on every run did, against the transport reused by proberOf
+func BenchmarkProbeCycle(b *testing.B) {
+	s := healthz(b)
+	load(b, probeYAML("bench", s.URL))
+	c := conf.Get("bench")
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := New(false).Client(c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reused", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := check(c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
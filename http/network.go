@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/conf"
+)
+
+// WithProxy send requests through proxy, conf.ProxyNone, conf.ProxyEnvironment
+// honoring NO_PROXY CIDRs, or a proxy URL
+func (p ProbeHTTP) WithProxy(proxy string) (ProbeHTTP, error) {
+	switch proxy {
+	case "", conf.ProxyNone:
+		p.transport.Proxy = http.ProxyURL(nil)
+	case conf.ProxyEnvironment:
+		p.transport.Proxy = NewProxyWithNoProxyCIDR(http.ProxyFromEnvironment)
+	default:
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return p, err
+		}
+		p.transport.Proxy = http.ProxyURL(u)
+	}
+	return p, nil
+}
+
+// DialContext dial function of conf as its http requests are dialed, to
+// Socket, or from SourceIP or Interface to hosts of Resolve at their IP
+func DialContext(conf *conf.Conf) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if conf.Env.Socket != "" {
+		return socketDialer(conf.Env.Socket)
+	}
+	if conf.Env.SourceIP != "" || conf.Env.Interface != "" || len(conf.Env.Resolve) > 0 {
+		return dialer(net.ParseIP(conf.Env.SourceIP), conf.Env.Interface, conf.Env.Resolve)
+	}
+	return defaultTransport.DialContext
+}
+
+// dialer dial from source IP or first address of iface matching the
+// target address family, connecting to hosts of resolve at their static IP.
+// URL host, and so Host header and TLS server name, are kept.
+func dialer(source net.IP, iface string, resolve map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	hosts := make(map[string]string, len(resolve))
+	for host, ip := range resolve {
+		hosts[strings.ToLower(strings.TrimSuffix(host, "."))] = ip
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if ip, ok := hosts[strings.ToLower(strings.TrimSuffix(host, "."))]; ok {
+			addr = net.JoinHostPort(ip, port)
+		}
+
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		local := source
+		if iface != "" {
+			if local, err = interfaceIP(iface, addr); err != nil {
+				return nil, err
+			}
+		}
+		if local != nil {
+			dialer.LocalAddr = &net.TCPAddr{IP: local}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// socketDialer dial unix domain socket path for every connection, URL host
+// only names the virtual host
+func socketDialer(path string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
+
+// interfaceIP first address of iface within the address family of addr,
+// IPv4 when addr is a host name
+func interfaceIP(iface, addr string) (net.IP, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	ip := net.ParseIP(host)
+	v4 := ip == nil || ip.To4() != nil
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && (ipnet.IP.To4() != nil) == v4 && !ipnet.IP.IsLinkLocalUnicast() {
+			return ipnet.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("Interface %s has no address to reach %s", iface, addr)
+}
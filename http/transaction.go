@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/failure"
+)
+
+// Transaction run Steps of conf in order sharing cookies, values captured
+// by a step replace {{name}} references of later steps.
+func (p ProbeHTTP) Transaction(conf *conf.Conf) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout:       time.Duration(conf.Env.ProtocolTimeout) * time.Second,
+		Transport:     p.transport,
+		CheckRedirect: p.checkRedirect,
+		Jar:           jar,
+	}
+
+	values := make(map[string]string)
+	for i := range conf.Env.Steps {
+		step := &conf.Env.Steps[i]
+		if err := runStep(conf.Target(), step, client, values); err != nil {
+			return fmt.Errorf("Step %s: %w", step.Name, err)
+		}
+	}
+	return nil
+}
+
+// runStep send step request against target, adding its captures to values
+func runStep(target *url.URL, step *conf.Step, client Method, values map[string]string) error {
+	path, err := conf.Substitute(step.Path, values)
+	if err != nil {
+		return err
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	request, err := step.Request.Substitute(values)
+	if err != nil {
+		return err
+	}
+
+	res, body, err := send(
+		target.ResolveReference(ref),
+		strings.ToUpper(step.RequestType),
+		client,
+		&request,
+		step.Assertion(),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range step.Capture {
+		capture := &step.Capture[i]
+		value, err := captureValue(capture, res, body)
+		if err != nil {
+			return err
+		}
+		values[capture.Name] = value
+	}
+	return nil
+}
+
+// captureValue value of capture within response res with body
+func captureValue(capture *conf.Capture, res *http.Response, body []byte) (string, error) {
+	switch {
+	case capture.Cookie != "":
+		for _, cookie := range res.Cookies() {
+			if cookie.Name == capture.Cookie {
+				return cookie.Value, nil
+			}
+		}
+		return "", &failure.BodyError{Reason: fmt.Sprintf("cookie %s missing", capture.Cookie)}
+	case capture.Header != "":
+		if values, ok := res.Header[http.CanonicalHeaderKey(capture.Header)]; ok && len(values) > 0 {
+			return values[0], nil
+		}
+		return "", &failure.BodyError{Reason: fmt.Sprintf("header %s missing", capture.Header)}
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", &failure.BodyError{Reason: fmt.Sprintf("body NOT JSON: %v", err)}
+	}
+	v, ok := capture.Path().Select(doc)
+	if !ok {
+		return "", &failure.BodyError{Reason: fmt.Sprintf("%s not found", capture.Path())}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	buf, err := json.Marshal(v)
+	return string(buf), err
+}
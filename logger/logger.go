@@ -21,6 +21,12 @@ func Init() (err error) {
 	return
 }
 
+// fallback logs msg to standard error until Init connects to syslog, e.g. in tests
+func fallback(severity, msg string) error {
+	log.Printf("%s %s", severity, msg)
+	return nil
+}
+
 // Close closes a connection to the syslog daemon.
 func Close() error {
 	return sysLog.Close()
@@ -29,41 +35,69 @@ func Close() error {
 // Crit logs a message with severity LOG_CRIT
 func Crit(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Crit(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("CRIT", msg)
+	}
+	return sysLog.Crit(msg)
 }
 
 // Alert logs a message with severity LOG_ALERT
 func Alert(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Alert(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("ALERT", msg)
+	}
+	return sysLog.Alert(msg)
 }
 
 // Debug logs a message with severity LOG_DEBUG
 func Debug(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Debug(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("DEBUG", msg)
+	}
+	return sysLog.Debug(msg)
 }
 
 // Emerg logs a message with severity LOG_EMERG
 func Emerg(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Emerg(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("EMERG", msg)
+	}
+	return sysLog.Emerg(msg)
 }
 
 // Err logs a message with severity LOG_ERR
 func Err(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Err(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("ERR", msg)
+	}
+	return sysLog.Err(msg)
 }
 
 // Info logs a message with severity LOG_INFO
 func Info(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Info(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("INFO", msg)
+	}
+	return sysLog.Info(msg)
 }
 
 // Warning logs a message with severity LOG_WARNING
 func Warning(m string) error {
 	_, fn, line, _ := runtime.Caller(1)
-	return sysLog.Warning(fmt.Errorf("%s:%d %v", filepath.Base(fn), line, m).Error())
+	msg := fmt.Sprintf("%s:%d %v", filepath.Base(fn), line, m)
+	if sysLog == nil {
+		return fallback("WARNING", msg)
+	}
+	return sysLog.Warning(msg)
 }
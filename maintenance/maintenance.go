@@ -0,0 +1,110 @@
+package maintenance
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+	"github.com/google/uuid"
+)
+
+type (
+	// Silence ad-hoc maintenance window, probes keep running but actions and alerts are suppressed
+	Silence struct {
+		ID      string    `json:"id"`
+		Probes  []string  `json:"probes"` // probe names, empty selects every probe
+		Comment string    `json:"comment,omitempty"`
+		Start   time.Time `json:"start"`
+		End     time.Time `json:"end"` // expiry
+	}
+)
+
+var (
+	mutex    sync.Mutex
+	silences = make(map[string]Silence)
+)
+
+// prune drop expired silences, mutex held
+func prune(now time.Time) {
+	for id, s := range silences {
+		if !now.Before(s.End) {
+			delete(silences, id)
+		}
+	}
+}
+
+// selects reports whether silence applies to probe name
+func (s Silence) selects(name string) bool {
+	if len(s.Probes) == 0 {
+		return true
+	}
+	for _, probe := range s.Probes {
+		if strings.EqualFold(probe, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add register silence, Start defaults to now, returns silence with its ID
+func Add(s Silence) (Silence, error) {
+	now := clock.Now()
+	if s.Start.IsZero() {
+		s.Start = now
+	}
+	if !s.End.After(s.Start) || !s.End.After(now) {
+		return s, errors.New("Silence end must follow start and now")
+	}
+	uuid, _ := uuid.NewRandom()
+	s.ID = uuid.String()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	prune(now)
+	silences[s.ID] = s
+	return s, nil
+}
+
+// Remove expire silence id early, false when unknown
+func Remove(id string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := silences[id]; !ok {
+		return false
+	}
+	delete(silences, id)
+	return true
+}
+
+// List unexpired silences ordered by start
+func List() []Silence {
+	mutex.Lock()
+	defer mutex.Unlock()
+	prune(clock.Now())
+
+	list := make([]Silence, 0, len(silences))
+	for _, s := range silences {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Start.Before(list[j].Start)
+	})
+	return list
+}
+
+// Active reports whether a silence covering probe name is in effect at now
+func Active(name string, now time.Time) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	prune(now)
+
+	for _, s := range silences {
+		if !now.Before(s.Start) && s.selects(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -21,17 +21,89 @@ var (
 			Help: "True/False Prometheus Kubelet daemon running.",
 		},
 	)
+	isWebSocketRunning = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "is_websocket_running",
+			Help: "True/False last websocket probe succeeded.",
+		},
+	)
 	restartCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "restart_count",
 			Help: "Count of all restart.",
 		},
 	)
+	probeStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "probe_status",
+			Help: "Probe status 0 unknown, 1 healthy, 2 failing, 3 blocked by dependency, 4 flapping, 5 degraded.",
+		},
+		[]string{"probe"},
+	)
+	probeFlapping = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "is_probe_flapping",
+			Help: "True/False probe alternating between healthy and failing.",
+		},
+		[]string{"probe"},
+	)
+	suppressedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "suppressed_count",
+			Help: "Count of actions suppressed by maintenance windows and silences.",
+		},
+		[]string{"probe"},
+	)
+	errorCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "probe_error_count",
+			Help: "Count of probe errors by class.",
+		},
+		[]string{"probe", "class"},
+	)
+	actionOutcomeCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "action_outcome_count",
+			Help: "Count of verified actions by outcome, recovered or unrecovered.",
+		},
+		[]string{"probe", "outcome"},
+	)
+	recoverySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "recovery_seconds",
+			Help:    "Time from action to recovery of probe in seconds.",
+			Buckets: prometheus.ExponentialBuckets(5, 2, 10),
+		},
+		[]string{"probe"},
+	)
+	probeLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "probe_latency_seconds",
+			Help: "Duration of last probe check in seconds.",
+		},
+		[]string{"probe"},
+	)
+	probeEscalated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "is_probe_escalated",
+			Help: "True/False actions withheld after repeated unrecovered actions.",
+		},
+		[]string{"probe"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(isDockerRunning)
 	prometheus.MustRegister(isKubeletRunning)
+	prometheus.MustRegister(isWebSocketRunning)
+	prometheus.MustRegister(probeStatus)
+	prometheus.MustRegister(probeFlapping)
+	prometheus.MustRegister(suppressedCount)
+	prometheus.MustRegister(errorCount)
+	prometheus.MustRegister(actionOutcomeCount)
+	prometheus.MustRegister(recoverySeconds)
+	prometheus.MustRegister(probeEscalated)
+	prometheus.MustRegister(probeLatency)
 	if err := prometheus.Register(restartCount); err != nil {
 		logger.Warning(err.Error())
 		panic(err)
@@ -53,11 +125,56 @@ func SetKubeletMetric(val float64) {
 	isKubeletRunning.Set(val)
 }
 
+// SetWebSocketMetric set whether last websocket probe succeeded.
+func SetWebSocketMetric(val float64) {
+	isWebSocketRunning.Set(val)
+}
+
 // IncrementRestartCount deletes all metrics in this vector.
 func IncrementRestartCount() {
 	restartCount.Inc()
 }
 
+// SetProbeStatus set status of probe.
+func SetProbeStatus(probe string, val float64) {
+	probeStatus.WithLabelValues(probe).Set(val)
+}
+
+// SetProbeFlapping set whether probe is flapping.
+func SetProbeFlapping(probe string, val float64) {
+	probeFlapping.WithLabelValues(probe).Set(val)
+}
+
+// IncrementSuppressedCount count action of probe suppressed by maintenance.
+func IncrementSuppressedCount(probe string) {
+	suppressedCount.WithLabelValues(probe).Inc()
+}
+
+// IncrementErrorCount count error of class returned by probe.
+func IncrementErrorCount(probe, class string) {
+	errorCount.WithLabelValues(probe, class).Inc()
+}
+
+// IncrementActionOutcome count verified action of probe by outcome.
+func IncrementActionOutcome(probe, outcome string) {
+	actionOutcomeCount.WithLabelValues(probe, outcome).Inc()
+}
+
+// ObserveRecovery record time from action to recovery of probe.
+func ObserveRecovery(probe string, seconds float64) {
+	recoverySeconds.WithLabelValues(probe).Observe(seconds)
+}
+
+// SetProbeEscalated set whether actions of probe are withheld.
+func SetProbeEscalated(probe string, val float64) {
+	probeEscalated.WithLabelValues(probe).Set(val)
+}
+
+// SetProbeLatency set duration of last check of probe.
+func SetProbeLatency(probe string, seconds float64) {
+	probeLatency.WithLabelValues(probe).Set(seconds)
+}
+
 // Run expose metrics to prometheus.
 func Run(port *string) {
 	go func() {
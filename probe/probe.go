@@ -0,0 +1,360 @@
+package probe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/failure"
+	"github.com/epiphany-platform/health-monitor/logger"
+	"github.com/epiphany-platform/health-monitor/maintenance"
+	"github.com/epiphany-platform/health-monitor/metric"
+	"github.com/epiphany-platform/health-monitor/timer"
+)
+
+type (
+	// Handler package specific hooks driven by the shared probe state machine.
+	// Timers run on the clock package, tests substitute Check and Remediate
+	// and advance a clock.Fake through the retry, bounce and recovery flow.
+	Handler struct {
+		Package   string                 // conf Package served by handler
+		Type      int                    // timer type, unique across packages
+		SubType   int                    // timer subtype normal processing
+		Retry     int                    // timer subtype retry after failure
+		Wait      int                    // timer subtype waiting service recovery
+		Check     func(*conf.Conf) error // probe target once, errors classified by failure.Classify
+		Remediate func(*conf.Conf) error // bounce service, ActionFatal only
+		Metric    func(float64)          // liveness gauge 1 running 0 failed
+	}
+)
+
+const (
+	// probe status, conf is commonly shadowed by the probe configuration
+	healthy  = conf.StatusHealthy
+	failing  = conf.StatusFailing
+	blocked  = conf.StatusBlocked
+	flapping = conf.StatusFlapping
+	degraded = conf.StatusDegraded
+)
+
+// launch arm timer of subtype for conf after d
+func (h *Handler) launch(conf *conf.Conf, subType int, d time.Duration) {
+	timer.Launch(
+		timer.Name(conf.Env.Name),
+		timer.Duration(d),
+		timer.Type(h.Type),
+		timer.SubType(subType),
+		timer.User(conf),
+	)
+}
+
+// recoveryDelayTimer initiate Recovery Delay timer allow service to recover,
+// backed off while actions repeat, return delay
+func (h *Handler) recoveryDelayTimer(conf *conf.Conf) time.Duration {
+	d := conf.Backoff(conf.Env.RecoveryDelay, conf.NextWaitStep())
+	h.launch(conf, h.Wait, d)
+	logger.Info(
+		fmt.Sprintf("Service %s Probe Delayed %.0f secs, allowance recovery of resources.",
+			conf.Env.Name,
+			d.Seconds()),
+	)
+	return d
+}
+
+// bounceService remediate failed service when ActionFatal, verifying
+// remediation from the end of its recovery delay
+func (h *Handler) bounceService(conf *conf.Conf) {
+	remediated := false
+	if conf.Env.ActionFatal && h.Remediate != nil {
+		if err := h.Remediate(conf); err != nil {
+			logger.Err(err.Error())
+		} else {
+			conf.IncRestartCount()
+			metric.IncrementRestartCount()
+			remediated = true
+		}
+	}
+	actionAt := clock.Now()
+	wait := h.recoveryDelayTimer(conf)
+	if remediated {
+		conf.StartVerify(actionAt, wait)
+	}
+}
+
+// retryServiceTimer initiates timer to retry probe, backed off while failures repeat
+func (h *Handler) retryServiceTimer(conf *conf.Conf) {
+	d := conf.Backoff(conf.Env.RetryDelay, conf.NextRetryStep())
+	h.launch(conf, h.Retry, d)
+	logger.Info(fmt.Sprintf(
+		"Retrying Probe %s Service %s attempts Cur: %d Max: %d in %.0f secs",
+		conf.Env.Name,
+		conf.Env.Package,
+		conf.RetryCounter(),
+		conf.Env.Retries,
+		d.Seconds(),
+	))
+}
+
+// stable restart backoff once probe is healthy for BackoffReset
+func stable(conf *conf.Conf) {
+	healthy := conf.HealthyFor(clock.Now())
+	if healthy >= time.Duration(conf.Env.BackoffReset)*time.Second && conf.ResetBackoff() {
+		logger.Info(fmt.Sprintf("Probe %s stable %.0f secs, backoff reset.", conf.Env.Name, healthy.Seconds()))
+	}
+}
+
+// silenced reports whether probe is within a maintenance window or silence
+func silenced(conf *conf.Conf) bool {
+	now := clock.Now()
+	return conf.InMaintenance(now) || maintenance.Active(conf.Env.Name, now)
+}
+
+// alert log m at level, as Info while probe is silenced, maintenance
+// suppresses alerts along with actions
+func alert(conf *conf.Conf, level func(string) error, m string) {
+	if silenced(conf) {
+		level = logger.Info
+	}
+	level(m)
+}
+
+// setStatus record probe status and its metrics, logging changes
+func setStatus(conf *conf.Conf, status conf.Status) {
+	prev, cur := conf.SetStatus(
+		status,
+		clock.Now(),
+		time.Duration(conf.Env.FlapWindow)*time.Second,
+		conf.Env.FlapThreshold,
+	)
+	switch {
+	case cur == prev:
+	case cur == flapping:
+		alert(conf, logger.Warning, fmt.Sprintf(
+			"Probe %s flapping, %d status changes within %d secs",
+			conf.Env.Name,
+			conf.Env.FlapThreshold,
+			conf.Env.FlapWindow,
+		))
+	default:
+		logger.Info(fmt.Sprintf("Probe %s status %s -> %s", conf.Env.Name, prev, cur))
+	}
+	metric.SetProbeStatus(conf.Env.Name, float64(cur))
+	if cur == flapping {
+		metric.SetProbeFlapping(conf.Env.Name, 1)
+	} else {
+		metric.SetProbeFlapping(conf.Env.Name, 0)
+	}
+}
+
+// blockedBy name of first failing dependency of probe, empty when none
+func blockedBy(c *conf.Conf) string {
+	for _, name := range c.Env.DependsOn {
+		if dep := conf.Get(name); dep != nil && dep.Status().IsFailing() {
+			return name
+		}
+	}
+	return ""
+}
+
+// exceeded reason failures of probe require action, empty while retrying
+func exceeded(conf *conf.Conf, immediate string) string {
+	if immediate != "" {
+		return immediate
+	}
+	if conf.IncCounter() > conf.Env.Retries {
+		return fmt.Sprintf(
+			"Exceeded Retry attempts Cur: %d Max: %d",
+			conf.RetryCounter(),
+			conf.Env.Retries,
+		)
+	}
+	if failed, total, ok := conf.RatioExceeded(clock.Now()); ok {
+		return fmt.Sprintf("Exceeded failure ratio %d of %d checks failed", failed, total)
+	}
+	return ""
+}
+
+// restartService Check whether service needs restarting, withheld while dependency fails.
+// An immediate reason skips remaining retries.
+func (h *Handler) restartService(conf *conf.Conf, dependency string, immediate string) {
+	reason := exceeded(conf, immediate)
+	if reason == "" {
+		h.retryServiceTimer(conf)
+		return
+	}
+
+	conf.ResetCounter()
+	conf.ClearOutcomes()
+	if dependency != "" {
+		logger.Info(fmt.Sprintf(
+			"Blocked %s Service %s %s, action withheld by failing dependency %s",
+			conf.Env.Name,
+			conf.Env.Package,
+			reason,
+			dependency,
+		))
+		h.armTimer(conf)
+	} else if silenced(conf) {
+		logger.Info(fmt.Sprintf(
+			"Maintenance %s Service %s %s, action suppressed",
+			conf.Env.Name,
+			conf.Env.Package,
+			reason,
+		))
+		metric.IncrementSuppressedCount(conf.Env.Name)
+		h.armTimer(conf)
+	} else if conf.Escalated() {
+		logger.Warning(fmt.Sprintf(
+			"Escalated %s Service %s %s, action withheld after %d unrecovered actions",
+			conf.Env.Name,
+			conf.Env.Package,
+			reason,
+			conf.Unrecovered(),
+		))
+		h.armTimer(conf)
+	} else {
+		logger.Warning(fmt.Sprintf(
+			"Bouncing %s Service %s %s",
+			conf.Env.Name,
+			conf.Env.Package,
+			reason,
+		))
+		h.bounceService(conf)
+	}
+}
+
+// degrade report check slower than WarnLatency, taking action after
+// DegradedChecks consecutive degraded checks
+func (h *Handler) degrade(conf *conf.Conf, latency time.Duration) {
+	setStatus(conf, degraded)
+	conf.ResetCounter()
+	count := conf.IncDegraded()
+	alert(conf, logger.Warning, fmt.Sprintf(
+		"Degraded Probe %s Service %s latency %v exceeding %d ms, checks Cur: %d",
+		conf.Env.Name,
+		conf.Env.Package,
+		latency,
+		conf.Env.WarnLatency,
+		count,
+	))
+	if conf.Env.DegradedChecks > 0 && count >= conf.Env.DegradedChecks {
+		conf.ResetDegraded()
+		h.restartService(conf, blockedBy(conf), fmt.Sprintf("Degraded %d consecutive checks", count))
+		return
+	}
+	if conf.Env.Once {
+		logger.Info(fmt.Sprintf("Probe %s succeeded degraded, run once completed.", conf.Env.Name))
+		return
+	}
+	h.armTimer(conf)
+}
+
+// armTimer launch default probe timer on the probe schedule
+func (h *Handler) armTimer(conf *conf.Conf) {
+	timer.Launch(
+		timer.Name(conf.Env.Name),
+		timer.At(conf),
+		timer.Type(h.Type),
+		timer.SubType(h.SubType),
+		timer.User(conf),
+	)
+}
+
+// Run launch probe timers of every configured probe of handler package
+func (h *Handler) Run() {
+	conf.Run(h.Package, h.Type, h.SubType)
+}
+
+// Probe check probe named by tle, arming its next timer
+func (h *Handler) Probe(tle *timer.TLE) {
+	launched, _ := tle.User.(*conf.Conf)
+	conf := conf.Get(tle.Name)
+	if conf == nil || !strings.EqualFold(conf.Env.Package, h.Package) {
+		logger.Info(fmt.Sprintf("Probe %s removed from configuration, stopped.", tle.Name))
+		return
+	}
+	// timers of a probe removed and added again belong to its former State
+	if launched != nil && launched.State != conf.State {
+		return
+	}
+
+	start := clock.Now()
+	err := h.Check(conf)
+	latency := clock.Since(start)
+	metric.SetProbeLatency(conf.Env.Name, latency.Seconds())
+	slow := false
+	if err == nil {
+		slow, err = conf.GradeLatency(latency)
+	}
+	class, action := failure.Classify(err), failure.Fail
+	if err != nil {
+		action = conf.ErrorAction(class)
+		metric.IncrementErrorCount(conf.Env.Name, string(class))
+		msg := conf.Scrub(fmt.Sprintf("Probe %s error class %s action %s: %v", conf.Env.Name, class, action, err))
+		if action == failure.Ignore {
+			logger.Info(msg)
+		} else {
+			alert(conf, logger.Warning, msg)
+		}
+	}
+	ignored := action == failure.Ignore
+	if conf.RatioEnabled() && !ignored {
+		conf.RecordOutcome(err != nil, clock.Now())
+	}
+
+	if err == nil {
+		h.Metric(1)
+		if successes := conf.IncSuccess(); conf.Status().IsFailing() && successes < conf.Env.SuccessThreshold {
+			logger.Info(fmt.Sprintf(
+				"Recovering Probe %s Service %s successes Cur: %d Min: %d",
+				conf.Env.Name,
+				conf.Env.Package,
+				successes,
+				conf.Env.SuccessThreshold,
+			))
+			if _, ok := conf.Verifying(); ok {
+				h.verifyTimer(conf)
+			} else {
+				h.armTimer(conf)
+			}
+			return
+		}
+		verify(conf)
+		if slow {
+			h.degrade(conf, latency)
+			return
+		}
+		setStatus(conf, healthy)
+		conf.ResetCounter()
+		conf.ResetDegraded()
+		stable(conf)
+		if conf.Env.Once {
+			logger.Info(fmt.Sprintf("Probe %s succeeded, run once completed.", conf.Env.Name))
+			return
+		}
+		h.armTimer(conf)
+	} else {
+		h.Metric(0)
+		conf.ResetSuccess()
+		conf.ResetDegraded()
+		dependency := blockedBy(conf)
+		if dependency != "" {
+			setStatus(conf, blocked)
+		} else {
+			setStatus(conf, failing)
+		}
+		if h.verifying(conf) {
+			return
+		}
+		switch action {
+		case failure.Ignore:
+			h.armTimer(conf)
+		case failure.Remediate:
+			h.restartService(conf, dependency, fmt.Sprintf("Error class %s requires immediate action", class))
+		default:
+			h.restartService(conf, dependency, "")
+		}
+	}
+}
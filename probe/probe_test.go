@@ -0,0 +1,375 @@
+package probe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/conf/conftest"
+	"github.com/epiphany-platform/health-monitor/timer"
+)
+
+type (
+	// flow drives a Handler through its timers on a fake clock
+	flow struct {
+		t            *testing.T
+		pkg          pkgConf
+		clock        *clock.Fake
+		h            *Handler
+		name         string
+		start        time.Time
+		failures     int // checks failing before success, negative always
+		remediations int
+		remediateErr error
+	}
+
+	// event probe run at offset from start by timer subtype
+	event struct {
+		subType int
+		at      time.Duration
+	}
+
+	// pkgConf handler timer type and conf of a probe package
+	pkgConf struct {
+		pkg  string
+		typ  int
+		yaml string
+	}
+)
+
+// packages timer types and conf of the docker and http package handlers
+var packages = []pkgConf{
+	{"docker", 2001, ""},
+	{"http", 3001, "  IP: 127.0.0.1\n  Port: 10248\n  Path: /healthz\n"},
+}
+
+// newFlow run package of p and load its probe name, conf extended by extra,
+// on a fake clock
+func newFlow(t *testing.T, p pkgConf, name string, failures int, extra string) *flow {
+	pkg, typ := p.pkg, p.typ
+	f := &flow{
+		t:        t,
+		pkg:      p,
+		clock:    clock.NewFake(time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC)),
+		name:     name,
+		failures: failures,
+	}
+	f.start = f.clock.Now()
+	timer.SetClock(f.clock)
+	f.h = &Handler{
+		Package:   pkg,
+		Type:      typ,
+		SubType:   typ + 1,
+		Retry:     typ + 2,
+		Wait:      typ + 3,
+		Check:     f.check,
+		Remediate: f.remediate,
+		Metric:    func(float64) {},
+	}
+
+	// probes added once the package runs are launched by Unmarshal
+	f.h.Run()
+	f.load(extra)
+	t.Cleanup(f.stop)
+	return f
+}
+
+// load probe conf extended by extra along with further docs
+func (f *flow) load(extra string, docs ...string) {
+	doc := conftest.Probe(f.name, f.pkg.pkg, "  ActionFatal: true\n"+f.pkg.yaml+extra)
+	if err := conf.Unmarshal(conftest.File(append([]string{doc}, docs...)...)); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// stop remove probe and run its remaining timers out
+func (f *flow) stop() {
+	if err := conf.Unmarshal(nil); err != nil {
+		f.t.Fatal(err)
+	}
+	for timer.Active() {
+		f.step()
+	}
+	timer.SetClock(nil)
+}
+
+func (f *flow) check(*conf.Conf) error {
+	if f.failures == 0 {
+		return nil
+	}
+	f.failures--
+	return errors.New("connection refused")
+}
+
+func (f *flow) remediate(*conf.Conf) error {
+	f.remediations++
+	return f.remediateErr
+}
+
+// step advance clock to earliest timer and probe it
+func (f *flow) step() event {
+	deadline, ok := timer.Next()
+	if !ok {
+		f.t.Fatal("no timer armed")
+	}
+	f.clock.Advance(deadline.Sub(f.clock.Now()))
+	tle := timer.Await()
+	f.h.Probe(tle)
+	return event{tle.SubType, deadline.Sub(f.start)}
+}
+
+// run one step per wanted event, failing t on the first mismatch
+func (f *flow) run(want ...event) {
+	f.t.Helper()
+	for i, w := range want {
+		if got := f.step(); got != w {
+			f.t.Fatalf("step %d = subtype %d at %v, want subtype %d at %v", i, got.subType, got.at, w.subType, w.at)
+		}
+	}
+}
+
+// conf current configuration of probe
+func (f *flow) conf() *conf.Conf {
+	return conf.Get(f.name)
+}
+
+func TestRetryBounceRecovered(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			f := newFlow(t, p, "recovered-"+p.pkg, 4, "")
+			sub, retry, wait := p.typ+1, p.typ+2, p.typ+3
+
+			f.run(event{sub, 5 * time.Second}, event{retry, 10 * time.Second}, event{retry, 15 * time.Second})
+			if f.conf().Status() != conf.StatusFailing || f.remediations != 0 {
+				t.Fatalf("retrying: status %s remediations %d", f.conf().Status(), f.remediations)
+			}
+
+			// fourth failure exceeds Retries, bounce and wait RecoveryDelay
+			f.run(event{retry, 20 * time.Second})
+			if f.remediations != 1 || f.conf().RestartCount() != 1 {
+				t.Fatalf("remediations %d restarts %d, want 1", f.remediations, f.conf().RestartCount())
+			}
+			if _, ok := f.conf().Verifying(); !ok {
+				t.Fatal("bounce NOT under verification")
+			}
+
+			f.run(event{wait, 30 * time.Second}, event{sub, 35 * time.Second})
+			if _, ok := f.conf().Verifying(); ok || f.conf().Status() != conf.StatusHealthy {
+				t.Errorf("after recovery: verifying %v status %s", ok, f.conf().Status())
+			}
+			if f.conf().RetryCounter() != 0 || f.conf().Unrecovered() != 0 {
+				t.Errorf("after recovery: retries %d unrecovered %d", f.conf().RetryCounter(), f.conf().Unrecovered())
+			}
+		})
+	}
+}
+
+func TestRetryBounceUnrecoveredEscalates(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			f := newFlow(t, p, "unrecovered-"+p.pkg, -1, "  MaxUnrecovered: 1\n")
+			sub, retry, wait := p.typ+1, p.typ+2, p.typ+3
+
+			f.run(
+				event{sub, 5 * time.Second},
+				event{retry, 10 * time.Second},
+				event{retry, 15 * time.Second},
+				event{retry, 20 * time.Second},
+				// RecoveryDelay, then VerifyInterval until RecoveryDelay+VerifyTimeout
+				event{wait, 30 * time.Second},
+				event{wait, 35 * time.Second},
+				event{wait, 40 * time.Second},
+			)
+			if f.conf().Unrecovered() != 0 {
+				t.Fatal("unrecovered before verification deadline")
+			}
+
+			f.run(event{wait, 45 * time.Second})
+			if f.conf().Unrecovered() != 1 || !f.conf().Escalated() {
+				t.Fatalf("unrecovered %d escalated %v, want 1 true", f.conf().Unrecovered(), f.conf().Escalated())
+			}
+
+			// retries exceeded again, action withheld once escalated
+			f.run(
+				event{retry, 50 * time.Second},
+				event{retry, 55 * time.Second},
+				event{retry, 60 * time.Second},
+				event{sub, 65 * time.Second},
+			)
+			if f.remediations != 1 {
+				t.Errorf("remediations %d, want 1 once escalated", f.remediations)
+			}
+
+			// recovery clears escalation
+			f.failures = 0
+			f.step()
+			if f.conf().Escalated() || f.conf().Status() != conf.StatusHealthy {
+				t.Errorf("after recovery: escalated %v status %s", f.conf().Escalated(), f.conf().Status())
+			}
+		})
+	}
+}
+
+func TestRemediateErrorKeepsProbing(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			f := newFlow(t, p, "remediate-"+p.pkg, -1, "")
+			f.remediateErr = errors.New("systemctl restart failed")
+			sub, retry, wait := p.typ+1, p.typ+2, p.typ+3
+
+			f.run(
+				event{sub, 5 * time.Second},
+				event{retry, 10 * time.Second},
+				event{retry, 15 * time.Second},
+				event{retry, 20 * time.Second},
+				event{wait, 30 * time.Second},
+			)
+			if f.remediations != 1 || f.conf().RestartCount() != 0 {
+				t.Errorf("remediations %d restarts %d, want 1 0", f.remediations, f.conf().RestartCount())
+			}
+			if _, ok := f.conf().Verifying(); ok {
+				t.Error("failed remediation under verification")
+			}
+		})
+	}
+}
+
+func TestBackedOffRecoveryDelayVerified(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			f := newFlow(t, p, "backoff-"+p.pkg, -1, "  BackoffFactor: 3\n")
+			wait := p.typ + 3
+
+			for f.remediations < 2 {
+				f.step()
+			}
+			bounced := f.clock.Now().Sub(f.start)
+
+			// second recovery delay backed off to 30 secs, beyond RecoveryDelay
+			// plus VerifyTimeout, then verified for VerifyTimeout 15 secs
+			f.run(event{wait, bounced + 30*time.Second})
+			if _, ok := f.conf().Verifying(); !ok || f.conf().Unrecovered() != 1 {
+				t.Fatalf("after backed off delay: verifying %v unrecovered %d, want true 1", ok, f.conf().Unrecovered())
+			}
+			f.run(
+				event{wait, bounced + 35*time.Second},
+				event{wait, bounced + 40*time.Second},
+				event{wait, bounced + 45*time.Second},
+			)
+			if f.conf().Unrecovered() != 2 {
+				t.Errorf("unrecovered %d, want 2 after VerifyTimeout", f.conf().Unrecovered())
+			}
+		})
+	}
+}
+
+// logged run fn capturing logger output, written by the standard logger
+// while syslog is not initialised
+func logged(fn func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
+func TestMaintenanceSuppressesAlerts(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			f := newFlow(t, p, "silenced-"+p.pkg, -1, "  Maintenance:\n  - From: \"00:00\"\n    To: \"00:00\"\n")
+			out := logged(func() {
+				for i := 0; i < 8; i++ {
+					f.step()
+				}
+			})
+			if f.remediations != 0 {
+				t.Errorf("remediations %d within maintenance, want 0", f.remediations)
+			}
+			for _, severity := range []string{"WARNING", "ERR", "CRIT"} {
+				if strings.Contains(out, severity+" ") {
+					t.Errorf("%s logged within maintenance:\n%s", severity, out)
+				}
+			}
+			if !strings.Contains(out, "action suppressed") {
+				t.Errorf("suppressed action NOT logged:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestFailureAlertsOutsideMaintenance(t *testing.T) {
+	f := newFlow(t, packages[0], "alerting", -1, "")
+	if out := logged(func() { f.step() }); !strings.Contains(out, "WARNING ") {
+		t.Errorf("failure NOT logged as WARNING:\n%s", out)
+	}
+}
+
+func TestFailingDependencyWithholdsAction(t *testing.T) {
+	for _, p := range packages {
+		t.Run(p.pkg, func(t *testing.T) {
+			dep := "dependency-" + p.pkg
+			f := newFlow(t, p, "dependent-"+p.pkg, -1, "")
+			// dependency of a package not running, its status set by the test
+			f.load("  DependsOn: ["+dep+"]\n", conftest.Probe(dep, "dependency", ""))
+			conf.Get(dep).SetStatus(conf.StatusFailing, f.clock.Now(), 0, 0)
+			sub, retry := p.typ+1, p.typ+2
+
+			f.run(
+				event{sub, 5 * time.Second},
+				event{retry, 10 * time.Second},
+				event{retry, 15 * time.Second},
+				event{retry, 20 * time.Second},
+				// action withheld, probe keeps its normal schedule
+				event{sub, 25 * time.Second},
+			)
+			if f.remediations != 0 || f.conf().Status() != conf.StatusBlocked {
+				t.Fatalf("dependency failing: remediations %d status %s, want 0 blocked", f.remediations, f.conf().Status())
+			}
+
+			conf.Get(dep).SetStatus(conf.StatusHealthy, f.clock.Now(), 0, 0)
+			f.run(
+				event{retry, 30 * time.Second},
+				event{retry, 35 * time.Second},
+				event{retry, 40 * time.Second},
+			)
+			if f.remediations != 1 || f.conf().Status() != conf.StatusFailing {
+				t.Errorf("dependency healthy: remediations %d status %s, want 1 failing", f.remediations, f.conf().Status())
+			}
+		})
+	}
+}
+
+func TestSuccessThresholdRecovery(t *testing.T) {
+	for _, c := range []struct {
+		threshold int
+		recovery  []int // subtype, offset from the timer type, of each success until healthy
+	}{
+		{1, []int{2}},
+		{2, []int{2, 1}},
+		{3, []int{2, 1, 1}},
+	} {
+		p := packages[0]
+		t.Run(fmt.Sprint(c.threshold), func(t *testing.T) {
+			f := newFlow(t, p, fmt.Sprintf("threshold-%d", c.threshold), 1, fmt.Sprintf("  SuccessThreshold: %d\n", c.threshold))
+			f.run(event{p.typ + 1, 5 * time.Second})
+
+			for i, offset := range c.recovery {
+				if f.conf().Status() != conf.StatusFailing {
+					t.Fatalf("after %d successes status %s, want failing", i, f.conf().Status())
+				}
+				f.run(event{p.typ + offset, time.Duration(10+5*i) * time.Second})
+			}
+			if f.conf().Status() != conf.StatusHealthy || f.conf().RetryCounter() != 0 {
+				t.Errorf("after %d successes status %s retries %d, want healthy 0",
+					len(c.recovery), f.conf().Status(), f.conf().RetryCounter())
+			}
+		})
+	}
+}
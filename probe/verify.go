@@ -0,0 +1,77 @@
+package probe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/logger"
+	"github.com/epiphany-platform/health-monitor/metric"
+)
+
+// verifyTimer initiates timer probing at VerifyInterval until action is verified
+func (h *Handler) verifyTimer(conf *conf.Conf) {
+	h.launch(conf, h.Wait, time.Duration(conf.Env.VerifyInterval)*time.Second)
+}
+
+// recovered close verification of action taken at actionAt, probe recovered
+func recovered(conf *conf.Conf, actionAt time.Time) {
+	elapsed := clock.Since(actionAt)
+	conf.EndVerify(true)
+	logger.Info(fmt.Sprintf(
+		"Recovered %s Service %s %.0f secs after action",
+		conf.Env.Name,
+		conf.Env.Package,
+		elapsed.Seconds(),
+	))
+	metric.IncrementActionOutcome(conf.Env.Name, "recovered")
+	metric.ObserveRecovery(conf.Env.Name, elapsed.Seconds())
+	metric.SetProbeEscalated(conf.Env.Name, 0)
+}
+
+// unrecovered close verification of action taken at actionAt, probe still failing
+func unrecovered(conf *conf.Conf, actionAt time.Time) {
+	count := conf.EndVerify(false)
+	alert(conf, logger.Warning, fmt.Sprintf(
+		"Unrecovered %s Service %s %.0f secs after action, %d consecutive",
+		conf.Env.Name,
+		conf.Env.Package,
+		clock.Since(actionAt).Seconds(),
+		count,
+	))
+	metric.IncrementActionOutcome(conf.Env.Name, "unrecovered")
+	if conf.Escalated() {
+		alert(conf, logger.Err, fmt.Sprintf(
+			"Escalated %s Service %s, actions withheld after %d unrecovered actions",
+			conf.Env.Name,
+			conf.Env.Package,
+			count,
+		))
+		metric.SetProbeEscalated(conf.Env.Name, 1)
+	}
+}
+
+// verify close verification, if any, of healthy probe
+func verify(conf *conf.Conf) {
+	if actionAt, ok := conf.Verifying(); ok {
+		recovered(conf, actionAt)
+	} else if conf.ClearUnrecovered() > 0 {
+		metric.SetProbeEscalated(conf.Env.Name, 0)
+	}
+}
+
+// verifying re-arm failing probe at VerifyInterval until verification deadline,
+// false once no action is under verification
+func (h *Handler) verifying(conf *conf.Conf) bool {
+	actionAt, ok := conf.Verifying()
+	if !ok {
+		return false
+	}
+	if clock.Now().Before(conf.VerifyDeadline(actionAt)) {
+		h.verifyTimer(conf)
+		return true
+	}
+	unrecovered(conf, actionAt)
+	return false
+}
@@ -0,0 +1,42 @@
+package timer
+
+// tleHeap min-heap of armed TLEs ordered by deadline, implements heap.Interface
+type tleHeap []*TLE
+
+// Len number of armed timers
+func (h tleHeap) Len() int {
+	return len(h)
+}
+
+// Less earliest deadline first, launch order breaks ties
+func (h tleHeap) Less(i, j int) bool {
+	if h[i].deadline.Equal(h[j].deadline) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+// Swap exchange elements maintaining their heap index
+func (h tleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Push append TLE to heap
+func (h *tleHeap) Push(x interface{}) {
+	t := x.(*TLE)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+// Pop remove last TLE from heap
+func (h *tleHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
@@ -0,0 +1,217 @@
+package timer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Schedule computes the next expiry strictly after now, zero time when none
+	Schedule interface {
+		Next(now time.Time) time.Time
+	}
+
+	// ScheduleFunc adapts a function to a Schedule
+	ScheduleFunc func(now time.Time) time.Time
+
+	// Every fixed interval schedule
+	Every time.Duration
+
+	// Cron schedule parsed from a cron expression
+	Cron struct {
+		second, minute, hour, dom, month, dow uint64
+		domAny, dowAny                        bool
+	}
+
+	// cronField bounds and names of a single cron field
+	cronField struct {
+		min, max int
+		names    []string
+	}
+)
+
+var (
+	cronSeconds = cronField{min: 0, max: 59}
+	cronMinutes = cronField{min: 0, max: 59}
+	cronHours   = cronField{min: 0, max: 23}
+	cronDom     = cronField{min: 1, max: 31}
+	cronMonths  = cronField{min: 1, max: 12, names: []string{
+		"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronDow = cronField{min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Next now plus interval
+func (e Every) Next(now time.Time) time.Time {
+	return now.Add(time.Duration(e))
+}
+
+// value parse number or name of field
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("Cron value %q out-of-range %d-%d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// parse field list of *, a, a-b with optional /step into a bit set
+func (f cronField) parse(expr string) (uint64, bool, error) {
+	var bits uint64
+	for _, term := range strings.Split(expr, ",") {
+		rng, step := term, 1
+		if i := strings.Index(term, "/"); i >= 0 {
+			s, err := strconv.Atoi(term[i+1:])
+			if err != nil || s <= 0 {
+				return 0, false, fmt.Errorf("Cron step %q invalid", term)
+			}
+			rng, step = term[:i], s
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = f.value(rng[:i]); err != nil {
+				return 0, false, err
+			}
+			if hi, err = f.value(rng[i+1:]); err != nil {
+				return 0, false, err
+			}
+			// ranges of day-of-week may end on Sunday, e.g. Sat-Sun
+			if f.max == cronDow.max && hi == 0 {
+				hi = cronDow.max
+			}
+			if lo > hi {
+				return 0, false, fmt.Errorf("Cron range %q reversed", rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, false, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, expr == "*" || expr == "?", nil
+}
+
+// ParseCron parse "[second] minute hour day-of-month month day-of-week",
+// the optional leading seconds field defaults to 0.
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("Cron %q requires 5 or 6 fields", expr)
+	}
+
+	c := new(Cron)
+	var err error
+	if c.second, _, err = cronSeconds.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.minute, _, err = cronMinutes.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.hour, _, err = cronHours.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.dom, c.domAny, err = cronDom.parse(strings.Replace(fields[3], "?", "*", 1)); err != nil {
+		return nil, err
+	}
+	if c.month, _, err = cronMonths.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if c.dow, c.dowAny, err = cronDow.parse(strings.Replace(fields[5], "?", "*", 1)); err != nil {
+		return nil, err
+	}
+	// 7 is an alias of Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// ParseDays parse a day-of-week list such as "Mon-Fri" or "Sat,Sun", empty is every day
+func ParseDays(expr string) (uint64, error) {
+	if expr == "" {
+		expr = "*"
+	}
+	days, _, err := cronDow.parse(expr)
+	if days&(1<<7) != 0 {
+		days |= 1
+	}
+	return days, err
+}
+
+// dayMatches standard cron semantics, restricted day-of-month and day-of-week match either
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next first matching second after now, zero time when none within five years
+func (c *Cron) Next(now time.Time) time.Time {
+	t := now.Add(time.Second - time.Duration(now.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if c.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// At populate TLE expiry from schedule s
+func At(s Schedule) Option {
+	return func(t *TLE) {
+		t.schedule = s
+	}
+}
+
+// Next calls f(now)
+func (f ScheduleFunc) Next(now time.Time) time.Time {
+	return f(now)
+}
@@ -0,0 +1,84 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Monday
+	monday := time.Date(2020, 1, 6, 12, 7, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(2020, month, day, hour, min, sec, 0, time.UTC)
+	}
+
+	for _, c := range []struct {
+		expr      string
+		now, want time.Time
+	}{
+		{"*/15 * * * *", monday, at(1, 6, 12, 15, 0)},
+		{"5,50 * * * *", monday, at(1, 6, 12, 50, 0)},
+		{"0 9-17 * * *", at(1, 6, 17, 30, 0), at(1, 7, 9, 0, 0)},
+		{"0 8-18/5 * * *", monday, at(1, 6, 13, 0, 0)},
+		{"0 0 1 jul,DEC *", monday, at(7, 1, 0, 0, 0)},
+		{"0 0 * * Sat-sun", monday, at(1, 11, 0, 0, 0)},
+		{"0 0 * * 7", monday, at(1, 12, 0, 0, 0)},
+		{"0 0 * * 0", monday, at(1, 12, 0, 0, 0)},
+		// restricted day-of-month and day-of-week match either
+		{"0 0 13 * fri", monday, at(1, 10, 0, 0, 0)},
+		{"0 0 13 * fri", at(1, 10, 12, 0, 0), at(1, 13, 0, 0, 0)},
+		{"0 0 13 * *", monday, at(1, 13, 0, 0, 0)},
+		{"0 0 ? * mon", monday, at(1, 13, 0, 0, 0)},
+		// optional leading seconds field
+		{"*/20 * * * * *", monday, at(1, 6, 12, 7, 40)},
+		{"30 0 13 * * *", monday, at(1, 6, 13, 0, 30)},
+		// strictly after now
+		{"30 7 12 * * *", monday, at(1, 7, 12, 7, 30)},
+		{"0 0 31 2 *", monday, time.Time{}},
+	} {
+		cron, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) = %v", c.expr, err)
+			continue
+		}
+		if got := cron.Next(c.now); !got.Equal(c.want) {
+			t.Errorf("%q Next(%v) = %v, want %v", c.expr, c.now, got, c.want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) = nil, want error", expr)
+		}
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	for _, c := range []struct {
+		expr string
+		want uint64
+	}{
+		{"", 1<<7 - 1 | 1<<7},
+		{"Mon-Fri", 0x3e},
+		{"Sat,Sun", 0x41},
+		{"7", 1 | 1<<7},
+	} {
+		if got, err := ParseDays(c.expr); err != nil || got != c.want {
+			t.Errorf("ParseDays(%q) = %#x %v, want %#x", c.expr, got, err, c.want)
+		}
+	}
+}
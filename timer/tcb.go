@@ -1,39 +1,120 @@
 package timer
 
 import (
-	"reflect"
+	"container/heap"
 	"sync"
 	"time"
-	"github.com/epiphany-platform/health-monitor/channel"
+
+	"github.com/epiphany-platform/health-monitor/clock"
 	"github.com/google/uuid"
 )
 
+type (
+	// scheduler Timer Control Block, single goroutine servicing all armed TLEs
+	scheduler struct {
+		mutex       sync.Mutex
+		armed       tleHeap       // armed timers earliest deadline first
+		queued      int           // expired timers not yet received
+		seq         uint64        // launch sequence number
+		wake        chan struct{} // earliest deadline changed
+		completions chan *TLE     // expired timers
+	}
+)
+
+const (
+	// completionDepth expired timers buffered ahead of caller
+	completionDepth = 64
+)
+
 var (
-	mutex sync.Mutex
-	pool  = sync.Pool{
-		New: func() interface{} {
-			return &TLE{
-				Name:    "#default",
-				Type:    -1,
-				SubType: -1,
-				Key:     "",
-				chnl:    reflect.Value{},
-				timer:   &time.Timer{},
-			}
-		},
+	tcb = &scheduler{
+		wake:        make(chan struct{}, 1),
+		completions: make(chan *TLE, completionDepth),
 	}
 )
 
-// Function returns true/false whether acquired from list/heap
-func acquire() (tle *TLE, ok bool) {
-	tle, ok = pool.Get().(*TLE)
-	return
+// start scheduler goroutine
+func init() {
+	go tcb.run()
+}
+
+// notify wake scheduler to re-evaluate earliest deadline
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// insert arm TLE, O(log n)
+func (s *scheduler) insert(t *TLE) {
+	s.mutex.Lock()
+	s.seq++
+	t.seq = s.seq
+	heap.Push(&s.armed, t)
+	first := t.index == 0
+	s.mutex.Unlock()
+
+	if first {
+		s.notify()
+	}
 }
 
-// Release object into Pool.
-func release(tle *TLE) {
-	tle.Init()
-	pool.Put(tle)
+// remove disarm TLE, O(log n), false when already expired
+func (s *scheduler) remove(t *TLE) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if t.index < 0 || t.index >= len(s.armed) || s.armed[t.index] != t {
+		return false
+	}
+	heap.Remove(&s.armed, t.index)
+	return true
+}
+
+// expired pop earliest TLE when due, otherwise return delay until due
+func (s *scheduler) expired(now time.Time) (*TLE, time.Duration, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.armed) == 0 {
+		return nil, 0, false
+	}
+	if d := s.armed[0].deadline.Sub(now); d > 0 {
+		return nil, d, true
+	}
+	s.queued++
+	return heap.Pop(&s.armed).(*TLE), 0, true
+}
+
+// run deliver expired TLEs on completion channel
+func (s *scheduler) run() {
+	for {
+		now := clock.Now()
+		t, d, ok := s.expired(now)
+		if t != nil {
+			t.C = now
+			s.completions <- t
+			continue
+		}
+		if !ok {
+			d = time.Hour
+		}
+
+		wait := clock.NewTimer(d)
+		select {
+		case <-wait.C():
+		case <-s.wake:
+		}
+		wait.Stop()
+	}
+}
+
+// SetClock replace clock driving timers, nil restores the wall clock.
+// Armed timers keep their deadlines.
+func SetClock(c clock.Clock) {
+	clock.Set(c)
+	tcb.notify()
 }
 
 // Type Populate Timer Type of TLE
@@ -42,6 +123,7 @@ func Type(TypeID int) Option {
 		t.Type = TypeID
 	}
 }
+
 // User Populate Timer User specified interface{}
 func User(UserID interface{}) Option {
 	return func(t *TLE) {
@@ -70,34 +152,39 @@ func Key(KeyID string) Option {
 	}
 }
 
-// Timeout populate Timeout of TLE
+// Timeout populate Timeout of TLE in seconds
 func Timeout(TimeoutID int) Option {
 	return func(t *TLE) {
 		if TimeoutID > 0 {
-			t.timer = time.NewTimer(time.Duration(TimeoutID) * time.Second)
+			t.duration = time.Duration(TimeoutID) * time.Second
 		}
 	}
 }
 
-// Chan populate Chan of TLE
-func Chan(ChanID reflect.Value) Option {
+// Duration populate Timeout of TLE
+func Duration(DurationID time.Duration) Option {
 	return func(t *TLE) {
-		t.chnl = ChanID
+		if DurationID > 0 {
+			t.duration = DurationID
+		}
 	}
 }
 
-// Launch a new Timer List Element (TLE) goroutine
+// Launch arm a new Timer List Element (TLE)
 func Launch(opts ...Option) (*TLE, bool) {
-	defer mutex.Unlock()
-	mutex.Lock()
-
-	tle, _ := acquire()
+	tle := newTLE()
 
 	for _, opt := range opts {
 		opt(tle)
 	}
 
-	if tle.timer == nil {
+	now := clock.Now()
+	if tle.schedule != nil {
+		tle.deadline = tle.schedule.Next(now)
+	} else if tle.duration > 0 {
+		tle.deadline = now.Add(tle.duration)
+	}
+	if tle.deadline.IsZero() {
 		return nil, false
 	}
 
@@ -105,12 +192,40 @@ func Launch(opts ...Option) (*TLE, bool) {
 		uuid, _ := uuid.NewRandom()
 		tle.Key = uuid.String()
 	}
-	if !tle.IsChannel() {
-		tle.chnl = channel.Make(tle, 1)
-	}
 
-	channel.Insert(tle.chnl, reflect.SelectRecv)
-
-	go tle.awaitio()
+	tcb.insert(tle)
 	return tle, true
 }
+
+// Await block until next TLE expires
+func Await() *TLE {
+	t := <-tcb.completions
+	tcb.mutex.Lock()
+	tcb.queued--
+	tcb.mutex.Unlock()
+	return t
+}
+
+// Len number of armed timers
+func Len() int {
+	tcb.mutex.Lock()
+	defer tcb.mutex.Unlock()
+	return len(tcb.armed)
+}
+
+// Next deadline of earliest armed timer, false when none is armed
+func Next() (time.Time, bool) {
+	tcb.mutex.Lock()
+	defer tcb.mutex.Unlock()
+	if len(tcb.armed) == 0 {
+		return time.Time{}, false
+	}
+	return tcb.armed[0].deadline, true
+}
+
+// Active reports whether any timer is armed or expired awaiting receipt
+func Active() bool {
+	tcb.mutex.Lock()
+	defer tcb.mutex.Unlock()
+	return len(tcb.armed)+tcb.queued > 0
+}
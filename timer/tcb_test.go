@@ -0,0 +1,104 @@
+package timer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	// benchSizes armed timers, hundreds of probes per node with retry and wait timers
+	benchSizes = []int{1000, 5000, 10000}
+)
+
+// arm launch n timers far in the future, cancelled once tb completes
+func arm(tb testing.TB, n int) []*TLE {
+	tles := make([]*TLE, 0, n)
+	for i := 0; i < n; i++ {
+		tle, ok := Launch(
+			Name(fmt.Sprintf("probe-%d", i)),
+			Duration(time.Hour+time.Duration(i)*time.Millisecond),
+		)
+		if !ok {
+			tb.Fatal("Launch failed")
+		}
+		tles = append(tles, tle)
+	}
+	tb.Cleanup(func() {
+		for _, tle := range tles {
+			tle.Cancel()
+		}
+	})
+	return tles
+}
+
+// BenchmarkLaunch arm and disarm one timer among n armed timers
+func BenchmarkLaunch(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			arm(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tle, _ := Launch(Name("bench"), Key("bench"), Duration(30*time.Minute))
+				tle.Cancel()
+			}
+		})
+	}
+}
+
+// BenchmarkCancel disarm timers from the middle of the heap
+func BenchmarkCancel(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			tles := arm(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tle := tles[i%n]
+				tle.Cancel()
+				tcb.insert(tle)
+			}
+		})
+	}
+}
+
+// BenchmarkAwait expire and receive one timer among n armed timers
+func BenchmarkAwait(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			arm(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Launch(Name("bench"), Key("bench"), Duration(time.Nanosecond))
+				Await()
+			}
+		})
+	}
+}
+
+// BenchmarkReflectSelect completion of one timer as previously received,
+// reflect.Select over one channel per armed timer
+func BenchmarkReflectSelect(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cases := make([]reflect.SelectCase, n)
+			for i := range cases {
+				cases[i] = reflect.SelectCase{
+					Dir:  reflect.SelectRecv,
+					Chan: reflect.ValueOf(make(chan *TLE, 1)),
+				}
+			}
+			ready := cases[n/2].Chan
+			tle := newTLE()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ready.Send(reflect.ValueOf(tle))
+				reflect.Select(cases)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package timer
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/clock"
+)
+
+// fake drive scheduler with a fake clock for the duration of t
+func fake(t *testing.T) *clock.Fake {
+	f := clock.NewFake(time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC))
+	SetClock(f)
+	t.Cleanup(func() { SetClock(nil) })
+	return f
+}
+
+// await receive next TLE or fail after a wall clock second
+func await(t *testing.T) *TLE {
+	done := make(chan *TLE, 1)
+	go func() { done <- Await() }()
+	select {
+	case tle := <-done:
+		return tle
+	case <-time.After(time.Second):
+		t.Fatal("Await timed out")
+	}
+	return nil
+}
+
+func TestAwaitDeadlineOrder(t *testing.T) {
+	f := fake(t)
+	for _, d := range []int{3, 1, 2} {
+		if _, ok := Launch(Name(fmt.Sprint(d)), Timeout(d)); !ok {
+			t.Fatalf("Launch %d failed", d)
+		}
+	}
+	f.Advance(3 * time.Second)
+
+	for _, want := range []string{"1", "2", "3"} {
+		if tle := await(t); tle.Name != want {
+			t.Errorf("Await = %s, want %s", tle.Name, want)
+		}
+	}
+	if Active() {
+		t.Error("Active after every timer was received")
+	}
+}
+
+func TestAwaitLaunchOrderOnEqualDeadline(t *testing.T) {
+	f := fake(t)
+	for i := 0; i < 5; i++ {
+		Launch(Name(fmt.Sprint(i)), Timeout(1))
+	}
+	f.Advance(time.Second)
+
+	for i := 0; i < 5; i++ {
+		if tle := await(t); tle.Name != fmt.Sprint(i) {
+			t.Errorf("Await = %s, want %d", tle.Name, i)
+		}
+	}
+}
+
+func TestLaunchWithoutDeadline(t *testing.T) {
+	if tle, ok := Launch(Name("none")); ok || tle != nil {
+		t.Error("Launch without Timeout, Duration or schedule armed a timer")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	f := fake(t)
+	tle, _ := Launch(Name("cancelled"), Timeout(1))
+	kept, _ := Launch(Name("kept"), Timeout(2))
+
+	if !tle.Cancel() {
+		t.Fatal("Cancel of armed timer = false")
+	}
+	if tle.Cancel() {
+		t.Error("second Cancel = true")
+	}
+	if Len() != 1 {
+		t.Errorf("Len = %d, want 1", Len())
+	}
+
+	f.Advance(2 * time.Second)
+	if got := await(t); got != kept {
+		t.Errorf("Await = %s, want kept", got.Name)
+	}
+	if kept.Cancel() {
+		t.Error("Cancel of expired timer = true")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	f := fake(t)
+	start := f.Now()
+	tle, _ := Launch(Name("every"), At(Every(10*time.Second)))
+	if want := start.Add(10 * time.Second); !tle.Deadline().Equal(want) {
+		t.Errorf("Deadline = %v, want %v", tle.Deadline(), want)
+	}
+	f.Advance(10 * time.Second)
+	if got := await(t); !got.C.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("C = %v, want deadline", got.C)
+	}
+}
+
+// TestStressLaunchCancelAwait launch and cancel timers from many goroutines
+// while the main loop receives them, every timer is either cancelled or
+// received exactly once
+func TestStressLaunchCancelAwait(t *testing.T) {
+	const (
+		launchers = 8
+		launches  = 500
+	)
+	var (
+		wg        sync.WaitGroup
+		launched  int64
+		cancelled int64
+	)
+
+	for i := 0; i < launchers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(i)))
+			for j := 0; j < launches; j++ {
+				tle, ok := Launch(
+					Name(fmt.Sprintf("stress-%d-%d", i, j)),
+					Duration(time.Duration(r.Intn(2000)+1)*time.Microsecond),
+				)
+				if !ok {
+					t.Error("Launch failed")
+					return
+				}
+				atomic.AddInt64(&launched, 1)
+				if r.Intn(2) == 0 && tle.Cancel() {
+					atomic.AddInt64(&cancelled, 1)
+				}
+			}
+		}(i)
+	}
+
+	completions := make(chan *TLE)
+	go func() {
+		for {
+			tle := Await()
+			completions <- tle
+			if tle.Name == "sentinel" {
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		Launch(Name("sentinel"), Duration(2*time.Millisecond))
+	}()
+
+	// sentinel expires after every launched timer, equal deadlines in launch order
+	received := make(map[*TLE]bool)
+	for {
+		select {
+		case tle := <-completions:
+			if received[tle] {
+				t.Fatalf("TLE %s received twice", tle.Name)
+			}
+			received[tle] = true
+			if tle.Name != "sentinel" {
+				continue
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d timers", len(received), atomic.LoadInt64(&launched)-atomic.LoadInt64(&cancelled))
+		}
+		break
+	}
+	if got, want := int64(len(received)-1), launched-cancelled; got != want {
+		t.Errorf("received %d timers, want %d", got, want)
+	}
+	if Active() {
+		t.Errorf("Active with %d armed after stress", Len())
+	}
+}
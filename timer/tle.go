@@ -2,13 +2,9 @@ package timer
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/epiphany-platform/health-monitor/channel"
-
-	"reflect"
-	"runtime"
-	"time"
 )
 
 type (
@@ -16,22 +12,20 @@ type (
 	Option func(*TLE)
 	// TLE Timer List Element used to manage timers.
 	TLE struct {
-		Name    string        // Timer name default #default
-		Type    int           // Timer type user defined default -1
-		SubType int           // Timer subtype user defined default -1
-		Key     string        // Timer Key user defined default ""
-		C       time.Time     // Timer instance completion nanoseconds
-		chnl    reflect.Value // Channel i/o completion
-		timer   *time.Timer   // Timer Event
-		User    interface{}   // User specfied value
+		Name     string        // Timer name default #default
+		Type     int           // Timer type user defined default -1
+		SubType  int           // Timer subtype user defined default -1
+		Key      string        // Timer Key user defined default ""
+		C        time.Time     // Timer instance completion nanoseconds
+		User     interface{}   // User specfied value
+		duration time.Duration // Timer interval
+		schedule Schedule      // Timer expiry schedule, overrides duration
+		deadline time.Time     // Timer expiry
+		seq      uint64        // Launch sequence, orders equal deadlines
+		index    int           // Scheduler heap index, -1 not armed
 	}
 )
 
-const (
-	// Completion case statement type
-	Completion = "*timer.TLE"
-)
-
 // Init initialize TLE with defaults, return address to caller
 func (t *TLE) Init() *TLE {
 	t.Name = "#default"
@@ -39,9 +33,13 @@ func (t *TLE) Init() *TLE {
 	t.SubType = -1
 	uuid, _ := uuid.NewRandom()
 	t.Key = uuid.String()
-	t.chnl = reflect.Value{}
-	t.timer = &time.Timer{}
+	t.C = time.Time{}
 	t.User = nil
+	t.duration = 0
+	t.schedule = nil
+	t.deadline = time.Time{}
+	t.seq = 0
+	t.index = -1
 	return t
 }
 
@@ -58,15 +56,6 @@ func (t *TLE) Equal(t2 *TLE) bool {
 		t.Key == t2.Key)
 }
 
-// delete stops specified Timer (TLE) and removes Link List element.
-func (t *TLE) delete() {
-	defer mutex.Unlock()
-	mutex.Lock()
-
-	t.chnl.Close()
-	release(t)
-}
-
 // New Construct and return TLE
 func (t *TLE) New() *TLE {
 	return &TLE{
@@ -75,21 +64,10 @@ func (t *TLE) New() *TLE {
 		SubType: t.SubType,
 		Key:     t.Key,
 		User:    t.User,
+		index:   -1,
 	}
 }
 
-// Awaitio wait timer completion and send completion via channel to caller.
-func (t *TLE) awaitio() {
-	select {
-	case t.C = <-t.timer.C:
-		{
-			channel.Send(t.chnl, t.New())
-			t.delete()
-		}
-	}
-	runtime.Goexit()
-}
-
 // Format returns formatted buffer based upon timer element
 func (t *TLE) Format() string {
 	return fmt.Sprintf(
@@ -100,22 +78,12 @@ func (t *TLE) Format() string {
 		t.Key)
 }
 
-// IsChannel reports whether t represents a channel value.
-func (t *TLE) IsChannel() bool {
-	return t.chnl.IsValid() && t.chnl.Kind() == reflect.Chan
+// Deadline returns time the timer expires
+func (t *TLE) Deadline() time.Time {
+	return t.deadline
 }
 
-// Cancel stops removes active timer.
-func (t *TLE) Cancel() {
-	defer mutex.Unlock()
-	mutex.Lock()
-
-	if t.timer != nil && !t.timer.Stop() {
-		_, _ = t.chnl.Recv()
-	}
-
-	if t.IsChannel() {
-		t.chnl.Close()
-	}
-	release(t)
+// Cancel disarm active timer, false when timer already expired.
+func (t *TLE) Cancel() bool {
+	return tcb.remove(t)
 }
@@ -0,0 +1,200 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/epiphany-platform/health-monitor/assert"
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/failure"
+	probehttp "github.com/epiphany-platform/health-monitor/http"
+	"github.com/epiphany-platform/health-monitor/metric"
+	"github.com/epiphany-platform/health-monitor/probe"
+	"github.com/epiphany-platform/health-monitor/timer"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	websocketPackage = "websocket"
+	// WebSocketTimerType must be unique across probes
+	WebSocketTimerType = 4001
+	// websocketTimerSubtype normal processing probes
+	websocketTimerSubtype = 4002
+	// websocketTimerRetry Retry logic enabled
+	websocketTimerRetry = 4003
+	// websocketTimerWait Wait websocket service recovers
+	websocketTimerWait = 4004
+)
+
+var (
+	// handler websocket hooks of the shared probe state machine, no remediation
+	handler = &probe.Handler{
+		Package: websocketPackage,
+		Type:    WebSocketTimerType,
+		SubType: websocketTimerSubtype,
+		Retry:   websocketTimerRetry,
+		Wait:    websocketTimerWait,
+		Check:   check,
+		Metric:  metric.SetWebSocketMetric,
+	}
+)
+
+// dial connect target of conf before deadline as http probes do, TLS for wss
+func dial(ctx context.Context, conf *conf.Conf, deadline time.Time) (net.Conn, error) {
+	target := conf.Target()
+	address := target.Host
+	if target.Port() == "" {
+		port := "80"
+		if target.Scheme == "wss" {
+			port = "443"
+		}
+		address = net.JoinHostPort(target.Hostname(), port)
+	}
+
+	conn, err := probehttp.DialContext(conf)(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(deadline)
+	if target.Scheme != "wss" {
+		return conn, nil
+	}
+
+	tlsConfig, err := conf.TLSConfig()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = target.Hostname()
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshakeConfig opening handshake of conf, Host, Origin and subprotocols
+// taken from the request headers
+func handshakeConfig(conf *conf.Conf) (*websocket.Config, error) {
+	target := conf.Target()
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.Env.Request.Apply(req); err != nil {
+		return nil, err
+	}
+
+	location := *target
+	if req.Host != "" {
+		location.Host = req.Host
+	}
+	origin := &url.URL{Scheme: "http", Host: location.Host}
+	if target.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+	if o := req.Header.Get("Origin"); o != "" {
+		if origin, err = url.Parse(o); err != nil {
+			return nil, fmt.Errorf("Origin header invalid: %v", err)
+		}
+		req.Header.Del("Origin")
+	}
+
+	config := &websocket.Config{
+		Location: &location,
+		Origin:   origin,
+		Version:  websocket.ProtocolVersionHybi13,
+		Header:   req.Header,
+	}
+	for _, p := range strings.Split(req.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			config.Protocol = append(config.Protocol, p)
+		}
+	}
+	return config, nil
+}
+
+// handshakeError classify failed opening handshake
+func handshakeError(err error) error {
+	switch err {
+	case websocket.ErrBadStatus:
+		return &failure.StatusError{Status: "NOT 101 Switching Protocols"}
+	case websocket.ErrBadUpgrade, websocket.ErrChallengeResponse, websocket.ErrUnsupportedExtensions:
+		return &failure.BodyError{Reason: err.Error()}
+	}
+	return err
+}
+
+// exchange send configured message and match reply, none when neither is set
+func exchange(ws *websocket.Conn, conf *conf.Conf) error {
+	if conf.Env.WebSocket.Send != "" {
+		if err := websocket.Message.Send(ws, conf.Env.WebSocket.Send); err != nil {
+			return err
+		}
+	} else if conf.Expect() == nil {
+		return nil
+	}
+
+	var reply []byte
+	switch err := websocket.Message.Receive(ws, &reply); err {
+	case nil:
+	case io.EOF:
+		return errors.New("websocket closed by server before reply")
+	case websocket.ErrFrameTooLarge:
+		return &failure.BodyError{Reason: fmt.Sprintf("message exceeds %d bytes", ws.MaxPayloadBytes)}
+	default:
+		return err
+	}
+	if expect := conf.Expect(); expect != nil && !expect.Match(reply) {
+		return &failure.BodyError{Reason: fmt.Sprintf("reply NOT matching %s", expect)}
+	}
+	return nil
+}
+
+// check upgrade to websocket and exchange message within ProtocolTimeout
+func check(conf *conf.Conf) error {
+	config, err := handshakeConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	// I/O deadlines are wall clock, as http.Client.Timeout of http probes
+	deadline := time.Now().Add(time.Duration(conf.Env.ProtocolTimeout) * time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	conn, err := dial(ctx, conf, deadline)
+	if err != nil {
+		return err
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return handshakeError(err)
+	}
+	// Close sends normal closure, status 1000
+	defer ws.Close()
+	ws.MaxPayloadBytes = assert.DefaultMaxBodySize
+	return exchange(ws, conf)
+}
+
+// Run launch specified client timer(s)
+func Run() {
+	handler.Run()
+}
+
+// Probe specified websocket endpoint
+func Probe(tle *timer.TLE) {
+	handler.Probe(tle)
+}
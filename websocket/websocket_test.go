@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiphany-platform/health-monitor/conf"
+	"github.com/epiphany-platform/health-monitor/conf/conftest"
+	"github.com/epiphany-platform/health-monitor/failure"
+	"golang.org/x/net/websocket"
+)
+
+// checkOf load probe name and check it once
+func checkOf(t *testing.T, name, url, extra string) error {
+	t.Helper()
+	if err := conf.Unmarshal(conftest.File(conftest.Probe(name, "websocket", "  URL: "+url+"\n"+extra))); err != nil {
+		t.Fatal(err)
+	}
+	return check(conf.Get(name))
+}
+
+// echo serve websocket echoing messages at /echo, closing at /close and
+// rejecting upgrades without the bearer token at /private
+func echo() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/echo", websocket.Handler(func(ws *websocket.Conn) {
+		var msg string
+		for websocket.Message.Receive(ws, &msg) == nil {
+			websocket.Message.Send(ws, msg)
+		}
+	}))
+	mux.Handle("/close", websocket.Handler(func(ws *websocket.Conn) {}))
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		websocket.Handler(func(ws *websocket.Conn) {}).ServeHTTP(w, r)
+	})
+	return mux
+}
+
+func TestCheck(t *testing.T) {
+	s := httptest.NewServer(echo())
+	defer s.Close()
+	ws := "ws" + strings.TrimPrefix(s.URL, "http")
+
+	for _, c := range []struct {
+		name, path, extra string
+		class             failure.Class
+	}{
+		{"upgrade", "/echo", "", ""},
+		{"reply", "/echo", "  WebSocket:\n    Send: ping\n    Expect: ^ping$\n", ""},
+		{"mismatch", "/echo", "  WebSocket:\n    Send: ping\n    Expect: ^pong$\n", failure.Body},
+		{"closed", "/close", "  WebSocket:\n    Send: ping\n", failure.Other},
+		{"status", "/private", "", failure.Status},
+		{"token", "/private", "  Request:\n    BearerToken: token\n", ""},
+		{"refused", "/echo", "  Socket: /nonexistent/ws.sock\n", failure.Refused},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkOf(t, "ws-"+c.name, ws+c.path, c.extra)
+			if got := failure.Classify(err); got != c.class {
+				t.Errorf("check = %v, class %q want %q", err, got, c.class)
+			}
+		})
+	}
+}
+
+func TestCheckSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "websocket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "ws.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewUnstartedServer(echo())
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+
+	extra := fmt.Sprintf("  Socket: %s\n  WebSocket:\n    Send: ping\n    Expect: ^ping$\n", filepath.Join(dir, "ws.sock"))
+	if err := checkOf(t, "ws-socket", "ws://localhost/echo", extra); err != nil {
+		t.Errorf("check over Socket = %v", err)
+	}
+}
+
+func TestProxyRejected(t *testing.T) {
+	err := conf.Unmarshal(conftest.File(conftest.Probe("ws-proxy", "websocket", "  URL: ws://localhost/echo\n  Proxy: environment\n")))
+	if err == nil {
+		t.Error("Unmarshal of websocket probe with Proxy = nil, want error")
+	}
+}
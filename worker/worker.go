@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"sync"
+)
+
+type (
+	// job unit of work queued against key
+	job struct {
+		key string
+		fn  func()
+	}
+
+	// Pool bounded set of workers, jobs sharing a key run serially in submission order
+	Pool struct {
+		mutex  sync.Mutex
+		jobs   chan job
+		active map[string][]func() // key running, followers queued
+	}
+)
+
+// New start workers goroutines accepting up to depth queued jobs
+func New(workers, depth int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if depth < workers {
+		depth = workers
+	}
+
+	p := &Pool{
+		jobs:   make(chan job, depth),
+		active: make(map[string][]func()),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// work run jobs, draining followers of the same key before taking another
+func (p *Pool) work() {
+	for j := range p.jobs {
+		for fn := j.fn; fn != nil; fn = p.next(j.key) {
+			fn()
+		}
+	}
+}
+
+// next pop following job of key, releases key when none remain
+func (p *Pool) next(key string) func() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	queue := p.active[key]
+	if len(queue) == 0 {
+		delete(p.active, key)
+		return nil
+	}
+	p.active[key] = queue[1:]
+	return queue[0]
+}
+
+// Submit queue fn against key without blocking, false when pool is saturated
+func (p *Pool) Submit(key string, fn func()) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if queue, ok := p.active[key]; ok {
+		p.active[key] = append(queue, fn)
+		return true
+	}
+
+	select {
+	case p.jobs <- job{key: key, fn: fn}:
+		p.active[key] = nil
+		return true
+	default:
+		return false
+	}
+}
+
+// Busy reports whether any job is queued or running
+func (p *Pool) Busy() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.active) > 0
+}